@@ -2,10 +2,10 @@ package commands
 
 import (
 	"fmt"
-	"os"
 	"sort"
 
-	composepkg "github.com/AkaraChen/dox/internal/compose"
+	"github.com/AkaraChen/dox/internal/config"
+	"github.com/AkaraChen/dox/internal/project"
 	"github.com/spf13/cobra"
 )
 
@@ -19,6 +19,7 @@ Aliases are custom command shortcuts defined in your dox.yaml file.
 They can chain multiple docker compose commands together.
 
 With no arguments, lists all available aliases.`,
+	ValidArgsFunction: completeAliases,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		if len(args) == 0 {
 			return listAliases()
@@ -39,21 +40,22 @@ func listAliases() error {
 		return err
 	}
 
-	if cfg == nil || len(cfg.Aliases) == 0 {
+	aliases := projectAliases(cfg)
+	if len(aliases) == 0 {
 		fmt.Println("No aliases defined in dox.yaml")
 		return nil
 	}
 
 	fmt.Println("Available aliases:")
 	// Sort alias names
-	names := make([]string, 0, len(cfg.Aliases))
-	for name := range cfg.Aliases {
+	names := make([]string, 0, len(aliases))
+	for name := range aliases {
 		names = append(names, name)
 	}
 	sort.Strings(names)
 
 	for _, name := range names {
-		fmt.Printf("  %s: %s\n", name, cfg.Aliases[name])
+		fmt.Printf("  %s: %s\n", name, aliases[name])
 	}
 
 	return nil
@@ -66,15 +68,14 @@ func executeAlias(aliasName string) error {
 		return err
 	}
 
-	if cfg == nil {
-		return fmt.Errorf("no dox.yaml found")
+	aliasDef, exists := lookupAlias(cfg, aliasName)
+	if !exists && cfg == nil {
+		return &config.MissingFileError{Path: "dox.yaml", Message: "no dox.yaml found"}
 	}
-
-	aliasDef, exists := cfg.Aliases[aliasName]
 	if !exists {
 		// List available aliases
 		var available []string
-		for name := range cfg.Aliases {
+		for name := range projectAliases(cfg) {
 			available = append(available, name)
 		}
 		return fmt.Errorf("alias '%s' not found. Available aliases: %v", aliasName, available)
@@ -84,27 +85,46 @@ func executeAlias(aliasName string) error {
 		fmt.Printf("Executing alias '%s': %s\n", aliasName, aliasDef)
 	}
 
-	commands, err := resolveAlias(aliasDef)
+	builder, err := newComposeBuilder(cfg)
 	if err != nil {
-		return fmt.Errorf("failed to resolve alias '%s': %w", aliasName, err)
+		return err
 	}
 
-	executor := getComposeExecutor()
-	dir, _ := os.Getwd()
-	executor.SetDir(dir)
+	steps, err := resolveAliasSteps(builder, cfg, aliasName, aliasDef)
+	if err != nil {
+		return fmt.Errorf("failed to resolve alias '%s': %w", aliasName, err)
+	}
 
-	// Show commands in dry-run mode
-	if IsDryRun() || IsVerbose() {
-		for _, cmd := range commands {
-			output := composepkg.FormatCommand(cmd)
-			printCommand(output)
-		}
+	plan, err := newPlan(cfg, builder)
+	if err != nil {
+		return err
 	}
+	plan.Add(steps...)
+	return runPlan(cfg, plan)
+}
 
-	if IsDryRun() {
+// projectAliases returns the aliases of dox.yaml, including those the
+// selected profile defines or inherits
+func projectAliases(cfg *config.Config) map[string]string {
+	if effective := activeProfile(cfg); effective != nil {
+		return effective.Aliases
+	}
+	if cfg == nil {
 		return nil
 	}
+	return cfg.Aliases
+}
+
+// lookupAlias finds an alias in dox.yaml, falling back to the global aliases
+// in ~/.config/dox/config.yaml
+func lookupAlias(cfg *config.Config, name string) (string, bool) {
+	if def, ok := projectAliases(cfg)[name]; ok {
+		return def, true
+	}
 
-	err = executor.RunInteractiveMultiple(commands)
-	return err
+	globalCfg, err := project.LoadGlobalConfig(project.GetGlobalConfigPath())
+	if err != nil || globalCfg == nil {
+		return "", false
+	}
+	return globalCfg.GetAlias(name)
 }
@@ -12,8 +12,9 @@ var buildCmd = &cobra.Command{
 
 Can build all services or specific services.`,
 	Args: cobra.ArbitraryArgs,
+	ValidArgsFunction: completeServices,
 	RunE: func(cmd *cobra.Command, args []string) error {
-	 return executeCommand(func(b *Builder, a []string) ([]string, error) {
+	 return executeCommand("build", func(b *Builder, a []string) ([]string, error) {
    return b.BuildBuild(a)
 	 }, args)
 	},
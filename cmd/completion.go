@@ -0,0 +1,157 @@
+package commands
+
+import (
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/AkaraChen/dox/internal/model"
+	"github.com/AkaraChen/dox/internal/project"
+	"github.com/spf13/cobra"
+)
+
+// completionCmd represents the completion command
+var completionCmd = &cobra.Command{
+	Use:   "completion [bash|zsh|fish|powershell]",
+	Short: "Generate the shell completion script",
+	Long: `Generate the completion script for the given shell.
+
+Completions cover commands and flags as well as profiles, aliases, service
+names and @project references, read from dox.yaml, the compose files and
+~/.config/dox/config.yaml. The docker daemon is never contacted.
+
+  bash:        source <(dox completion bash)
+  zsh:         dox completion zsh > "${fpath[1]}/_dox"
+  fish:        dox completion fish > ~/.config/fish/completions/dox.fish
+  powershell:  dox completion powershell | Out-String | Invoke-Expression`,
+	ValidArgs:             []string{"bash", "zsh", "fish", "powershell"},
+	Args:                  cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+	DisableFlagsInUseLine: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		switch args[0] {
+		case "bash":
+			return rootCmd.GenBashCompletionV2(out, true)
+		case "zsh":
+			return rootCmd.GenZshCompletion(out)
+		case "fish":
+			return rootCmd.GenFishCompletion(out, true)
+		default:
+			return rootCmd.GenPowerShellCompletionWithDesc(out)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(completionCmd)
+}
+
+// completeProfiles completes --profile from the profiles in dox.yaml
+func completeProfiles(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	cfg, err := getConfig()
+	if err != nil || cfg == nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	names := make([]string, 0, len(cfg.Profiles))
+	for name := range cfg.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeAliases completes the alias name from project and global aliases
+func completeAliases(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	seen := map[string]bool{}
+	if cfg, err := getConfig(); err == nil {
+		for name := range projectAliases(cfg) {
+			seen[name] = true
+		}
+	}
+	if globalCfg, err := project.LoadGlobalConfig(project.GetGlobalConfigPath()); err == nil && globalCfg != nil {
+		for _, name := range globalCfg.AliasNames() {
+			seen[name] = true
+		}
+	}
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeServices completes service names from the resolved compose files.
+// exec only takes a service as its first argument.
+func completeServices(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if cmd.Name() == "exec" && len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveDefault
+	}
+
+	builder, err := getFilesBuilder()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	files, err := builder.Files()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	stack, err := model.Load(files...)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var names []string
+	for _, name := range stack.ServiceNames() {
+		if !slices.Contains(args, name) {
+			names = append(names, name)
+		}
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeProjects completes @project references as the first argument
+func completeProjects(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 || (toComplete != "" && !strings.HasPrefix(toComplete, "@")) {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	globalCfg, err := project.LoadGlobalConfig(project.GetGlobalConfigPath())
+	if err != nil || globalCfg == nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	names := globalCfg.ProjectNames()
+	if !globalCfg.HasProject(project.AllProjectsName) {
+		names = append(names, project.AllProjectsName)
+	}
+	sort.Strings(names)
+
+	refs := make([]string, 0, len(names))
+	for _, name := range names {
+		refs = append(refs, "@"+name)
+	}
+	return refs, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeRemote completes the rest of an @project command line inside the
+// project directory, so profiles and services come from that project
+func completeRemote(ref string, args []string) error {
+	completeArgs := append([]string{cobra.ShellCompRequestCmd}, args...)
+
+	globalCfg, err := project.LoadGlobalConfigOrDefault(project.GetGlobalConfigPath())
+	if err == nil {
+		if target, err := globalCfg.ResolveRemoteProject(ref); err == nil {
+			return runInProject(target, completeArgs)
+		}
+	}
+
+	rootCmd.SetArgs(completeArgs)
+	return rootCmd.Execute()
+}
@@ -0,0 +1,119 @@
+package commands
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupGlobalConfig points HOME at a temp dir holding the given global config
+func setupGlobalConfig(t *testing.T, content string) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	dir := filepath.Join(home, ".config", "dox")
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "config.yaml"), []byte(content), 0644))
+}
+
+// captureCompletion runs a completion request and returns its output
+func captureCompletion(t *testing.T, run func() error) string {
+	var buf bytes.Buffer
+	rootCmd.SetOut(&buf)
+	rootCmd.SetErr(&bytes.Buffer{})
+	defer func() {
+		rootCmd.SetOut(nil)
+		rootCmd.SetErr(nil)
+		// cobra adds its hidden __complete command on demand; drop it so the
+		// command list stays as registered
+		for _, c := range rootCmd.Commands() {
+			if c.Name() == cobra.ShellCompRequestCmd {
+				rootCmd.RemoveCommand(c)
+			}
+		}
+	}()
+
+	require.NoError(t, run())
+	return buf.String()
+}
+
+func TestCompleteProfiles(t *testing.T) {
+	chdirFixture(t, "with-profiles")
+
+	names, directive := completeProfiles(composeGroupCmd, nil, "")
+	assert.Equal(t, []string{"dev", "full", "prod"}, names)
+	assert.Equal(t, cobra.ShellCompDirectiveNoFileComp, directive)
+
+	// The completion function is registered on the inherited --profile flag
+	out := captureCompletion(t, func() error {
+		rootCmd.SetArgs([]string{cobra.ShellCompRequestCmd, "c", "up", "--profile", ""})
+		defer func() { profile = "" }()
+		return rootCmd.Execute()
+	})
+	assert.Contains(t, out, "full\n")
+}
+
+func TestCompleteAliases(t *testing.T) {
+	chdirFixture(t, "with-aliases")
+	setupGlobalConfig(t, "aliases:\n  zap: \"down -v\"\n")
+
+	names, _ := completeAliases(aliasCmd, nil, "")
+	assert.Contains(t, names, "fresh")
+	assert.Contains(t, names, "zap")
+
+	names, _ = completeAliases(aliasCmd, []string{"fresh"}, "")
+	assert.Empty(t, names)
+}
+
+func TestCompleteServices(t *testing.T) {
+	chdirFixture(t, "services")
+
+	names, _ := completeServices(restartCmd, []string{"db"}, "")
+	assert.Equal(t, []string{"api-gateway", "worker-email", "worker-reports"}, names)
+
+	// exec completes the service only, not the command run inside it
+	names, directive := completeServices(execCmd, []string{"db"}, "")
+	assert.Empty(t, names)
+	assert.Equal(t, cobra.ShellCompDirectiveDefault, directive)
+}
+
+func TestCompleteProjects(t *testing.T) {
+	setupGlobalConfig(t, "projects:\n  web:\n    path: /tmp/web\n  api:\n    path: /tmp/api\n")
+
+	refs, _ := completeProjects(rootCmd, nil, "@")
+	assert.Equal(t, []string{"@all", "@api", "@web"}, refs)
+
+	refs, _ = completeProjects(rootCmd, nil, "c")
+	assert.Empty(t, refs)
+}
+
+func TestCompleteRemote_UsesProjectDirectory(t *testing.T) {
+	projectDir, err := filepath.Abs(filepath.Join("..", "test", "fixtures", "services"))
+	require.NoError(t, err)
+	setupGlobalConfig(t, "projects:\n  svc:\n    path: "+projectDir+"\n")
+
+	out := captureCompletion(t, func() error {
+		return completeRemote("@svc", []string{"c", "logs", "work"})
+	})
+	assert.Contains(t, out, "worker-email")
+	assert.Contains(t, out, "worker-reports")
+}
+
+func TestCompletionCmd(t *testing.T) {
+	for _, shell := range []string{"bash", "zsh", "fish", "powershell"} {
+		var buf bytes.Buffer
+		rootCmd.SetOut(&buf)
+		rootCmd.SetArgs([]string{"completion", shell})
+		require.NoError(t, rootCmd.Execute(), shell)
+		assert.True(t, strings.Contains(buf.String(), "dox"), shell)
+	}
+	rootCmd.SetOut(nil)
+
+	rootCmd.SetArgs([]string{"completion", "tcsh"})
+	assert.Error(t, rootCmd.Execute())
+}
@@ -2,18 +2,27 @@ package commands
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"slices"
-	"strings"
+	"sort"
 
 	composepkg "github.com/AkaraChen/dox/internal/compose"
 	"github.com/AkaraChen/dox/internal/config"
+	"github.com/AkaraChen/dox/internal/project"
 	"github.com/spf13/cobra"
 )
 
 var (
-	profile string
+	profile    string
+	noValidate bool
+
+	// configWarned is set once the warnings about dox.yaml were printed
+	// for the running command
+	configWarned bool
+	// warningOutput receives the warnings about dox.yaml
+	warningOutput io.Writer = os.Stderr
 )
 
 // Type aliases for use in other files
@@ -35,202 +44,352 @@ func init() {
 
 	// Global flags for compose commands
 	composeGroupCmd.PersistentFlags().StringVarP(&profile, "profile", "p", "", "profile to use from dox.yaml")
+	composeGroupCmd.RegisterFlagCompletionFunc("profile", completeProfiles)
+	composeGroupCmd.PersistentFlags().BoolVar(&noValidate, "no-validate", false, "skip checking service names against the compose files")
 }
 
 // getComposeBuilder creates a builder for the current directory
 func getComposeBuilder() (*Builder, error) {
-	dir, err := os.Getwd()
+	// Load config if exists
+	cfg, err := getConfig()
 	if err != nil {
 		return nil, err
 	}
+	return newComposeBuilder(cfg)
+}
 
-	// Load config if exists
-	cfg, _, err := config.LoadConfigFromDirectory(dir)
+// newComposeBuilder creates a builder for the current directory from an
+// already loaded config
+func newComposeBuilder(cfg *config.Config) (*Builder, error) {
+	builder, err := newFilesBuilder(cfg)
 	if err != nil {
 		return nil, err
 	}
 
-	// Use profile from flag or config
-	profileToUse := profile
-	if profileToUse == "" && cfg != nil {
-		profileToUse = cfg.GetDefaultProfile()
+	rt, err := resolveRuntime(cfg)
+	if err != nil {
+		return nil, err
 	}
+	builder.SetRuntime(rt)
+	return builder, nil
+}
 
-	return composepkg.NewBuilder(dir, cfg, profileToUse), nil
+// getFilesBuilder creates a builder for the current directory that is only
+// used to resolve compose files, so the container runtime is not detected
+func getFilesBuilder() (*Builder, error) {
+	cfg, err := getConfig()
+	if err != nil {
+		return nil, err
+	}
+	return newFilesBuilder(cfg)
 }
 
-// getConfig returns the config for current directory
-func getConfig() (*config.Config, error) {
+// newFilesBuilder creates a builder for the current directory with the
+// default runtime
+func newFilesBuilder(cfg *config.Config) (*Builder, error) {
 	dir, err := os.Getwd()
 	if err != nil {
 		return nil, err
 	}
 
-	cfg, _, err := config.LoadConfigFromDirectory(dir)
-	return cfg, err
+	profileToUse, _ := selectedProfile(cfg)
+	return composepkg.NewBuilder(dir, cfg, profileToUse), nil
 }
 
-// getComposeExecutor creates an executor with current settings
-func getComposeExecutor() *composepkg.Executor {
-	executor := composepkg.NewExecutor(IsDryRun())
-	if IsVerbose() {
-		executor.SetEnv([]string{"DOCKER_COMPOSE_VERBOSE=1"})
+// selectedProfile returns the profile to use from the --profile flag, the
+// @project registry entry or default_profile in dox.yaml, in that order,
+// and where it came from
+func selectedProfile(cfg *config.Config) (name, source string) {
+	if profile != "" {
+		return profile, "--profile flag"
 	}
-	return executor
-}
-
-// executeHooks executes hooks for a given hook type
-func executeHooks(hookType string) error {
-	cfg, err := getConfig()
-	if err != nil {
-		return err
+	if remoteEntry != nil && remoteEntry.Profile != "" {
+		return remoteEntry.Profile, "@project registry entry"
+	}
+	if cfg != nil && cfg.DefaultProfile != "" {
+		return cfg.DefaultProfile, "default_profile"
+	}
+	if cfg != nil && cfg.Defaults.Profile != "" {
+		return cfg.Defaults.Profile, "defaults.profile"
 	}
+	return "", ""
+}
 
-	if cfg == nil || cfg.Hooks == nil {
+// activeProfile returns the effective settings of the selected profile, or
+// nil without a dox.yaml or profile. Resolution errors surface when the
+// compose files are resolved.
+func activeProfile(cfg *config.Config) *config.EffectiveProfile {
+	name, _ := selectedProfile(cfg)
+	if cfg == nil || name == "" {
 		return nil
 	}
-
-	hooks, exists := cfg.Hooks[hookType]
-	if !exists || len(hooks) == 0 {
+	effective, err := cfg.EffectiveProfile(name)
+	if err != nil {
 		return nil
 	}
+	return effective
+}
 
-	executor := getComposeExecutor()
-	dir, _ := os.Getwd()
-	executor.SetDir(dir)
-
-	if IsVerbose() {
-		fmt.Printf("Executing %s hooks...\n", hookType)
+// resolveRuntime picks the container runtime from DOX_RUNTIME, dox.yaml or
+// the global config, in that order, and auto-detects it from PATH otherwise.
+// A dry run does not probe the runtimes it finds, since nothing is run.
+func resolveRuntime(cfg *config.Config) (composepkg.Runtime, error) {
+	projectRuntime := ""
+	if cfg != nil {
+		projectRuntime = cfg.Runtime
 	}
 
-	for _, hook := range hooks {
-		if IsDryRun() || IsVerbose() {
-			fmt.Printf("  hook: %s\n", hook)
-		}
+	globalRuntime := ""
+	if globalCfg, err := project.LoadGlobalConfig(project.GetGlobalConfigPath()); err == nil && globalCfg != nil {
+		globalRuntime = globalCfg.Runtime
+	}
 
-		if IsDryRun() {
-			continue
-		}
+	resolve := composepkg.ResolveRuntime
+	if IsDryRun() {
+		resolve = composepkg.ResolveRuntimeFromPath
+	}
+	rt, err := resolve(os.Getenv("DOX_RUNTIME"), projectRuntime, globalRuntime)
+	if err != nil {
+		return nil, &config.ConfigError{Err: err}
+	}
+	return rt, nil
+}
 
-		// Parse hook command
-		cmd := parseHookCommand(hook)
-		if err := executor.RunInteractive(cmd); err != nil {
-			return fmt.Errorf("hook failed: %s\nError: %w", hook, err)
-		}
+// getConfig returns the config for current directory, with ${DOX_PROFILE}
+// resolving to the profile selected on the command line
+func getConfig() (*config.Config, error) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	return loadConfigIn(dir)
 }
 
-// parseHookCommand parses a hook string into command arguments
-func parseHookCommand(hook string) []string {
-	// Simple parsing - split by spaces
-	// TODO: Handle quoted arguments properly
-	return strings.Fields(hook)
+// loadConfigIn loads the dox.yaml of dir, with ${DOX_PROFILE} resolving to
+// the profile selected on the command line. The first load of a command
+// prints the warnings about the file.
+func loadConfigIn(dir string) (*config.Config, error) {
+	explicit, _ := selectedProfile(nil)
+	cfg, _, err := config.LoadConfigForProfile(dir, explicit)
+	if err == nil && cfg != nil && !configWarned {
+		configWarned = true
+		warnConfig(warningOutput, cfg)
+	}
+	return cfg, err
 }
 
-// executeCommand builds and executes a command with hooks
-func executeCommand(buildFunc func(*Builder, []string) ([]string, error), args []string) error {
-	// Execute pre hooks if this is an 'up' command
-	// Determine command type from the build function
-	builder, err := getComposeBuilder()
-	if err != nil {
-		return err
+// commandEnv returns the environment additions for commands run by dox
+func commandEnv(cfg *config.Config) []string {
+	var env []string
+	if IsVerbose() {
+		env = append(env, "DOCKER_COMPOSE_VERBOSE=1")
 	}
-
-	cmd, err := buildFunc(builder, args)
-	if err != nil {
-		return err
+	if remoteEntry != nil {
+		env = append(env, remoteEntry.EnvList()...)
+	}
+	if effective := activeProfile(cfg); effective != nil {
+		keys := make([]string, 0, len(effective.Environment))
+		for key := range effective.Environment {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			env = append(env, key+"="+effective.Environment[key])
+		}
+		if effective.Context != "" {
+			env = append(env, "DOCKER_CONTEXT="+effective.Context)
+		}
 	}
+	return env
+}
 
-	executor := getComposeExecutor()
+// getComposeExecutor creates an executor with current settings
+func getComposeExecutor(cfg *config.Config) *composepkg.Executor {
+	executor := composepkg.NewExecutor(IsDryRun())
+	executor.GracePeriod = gracePeriod
+	if env := commandEnv(cfg); len(env) > 0 {
+		executor.SetEnv(env)
+	}
+	return executor
+}
 
-	// Set working directory
+// newStep creates a plan step that runs in the current directory
+func newStep(cfg *config.Config, stepType composepkg.StepType, argv []string, source string) composepkg.Step {
 	dir, _ := os.Getwd()
-	executor.SetDir(dir)
+	return composepkg.Step{
+		Type:   stepType,
+		Argv:   argv,
+		Dir:    dir,
+		Env:    commandEnv(cfg),
+		Source: source,
+	}
+}
 
-	// Check if this is an 'up' command and run pre_up hooks
-	if len(cmd) > 0 && cmd[len(cmd)-1] == "up" {
-		if err := executeHooks("pre_up"); err != nil {
-			return err
-		}
+// hookSteps returns the plan steps for the hooks of a given type
+func hookSteps(cfg *config.Config, hookType string) ([]composepkg.Step, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+	hooks := cfg.Hooks
+	if effective := activeProfile(cfg); effective != nil {
+		hooks = effective.Hooks
 	}
 
-	// Check if this is a 'down' command and run pre_down hooks
-	if len(cmd) > 0 && cmd[len(cmd)-1] == "down" {
-		if err := executeHooks("pre_down"); err != nil {
-			return err
+	var steps []composepkg.Step
+	for i, hook := range hooks[hookType] {
+		source := fmt.Sprintf("hooks.%s[%d]", hookType, i)
+		cmd, err := parseHookCommand(hook)
+		if err != nil {
+			return nil, &config.ConfigError{Path: cfg.Path, Err: fmt.Errorf("%s: %w", source, err)}
 		}
+		if len(cmd) == 0 {
+			continue
+		}
+		steps = append(steps, newStep(cfg, composepkg.StepHook, cmd, source))
 	}
+	return steps, nil
+}
 
-	if IsDryRun() || IsVerbose() {
-		output := composepkg.FormatCommand(cmd)
-		printCommand(output)
+// composeSteps wraps a compose command with the pre and post hooks of its verb
+func composeSteps(cfg *config.Config, verb string, cmd []string, source string) ([]composepkg.Step, error) {
+	pre, err := hookSteps(cfg, "pre_"+verb)
+	if err != nil {
+		return nil, err
+	}
+	post, err := hookSteps(cfg, "post_"+verb)
+	if err != nil {
+		return nil, err
 	}
+	steps := append(pre, newStep(cfg, composepkg.StepCompose, cmd, source))
+	return append(steps, post...), nil
+}
 
-	if IsDryRun() {
-		// Show post hooks in dry-run mode
-		if len(cmd) > 0 && cmd[len(cmd)-1] == "up" {
-			executeHooks("post_up")
+// newPlan creates an empty plan carrying the on_failure and finally hooks
+// from dox.yaml and notes about local overrides and skipped optional env files
+func newPlan(cfg *config.Config, builder *Builder) (*composepkg.Plan, error) {
+	plan := composepkg.NewPlan()
+	var err error
+	if plan.OnFailure, err = hookSteps(cfg, "on_failure"); err != nil {
+		return nil, err
+	}
+	if plan.Finally, err = hookSteps(cfg, "finally"); err != nil {
+		return nil, err
+	}
+	if cfg != nil {
+		for _, file := range cfg.Overrides {
+			plan.Notes = append(plan.Notes, fmt.Sprintf("using local override %s", filepath.Base(file)))
 		}
-		if len(cmd) > 0 && cmd[len(cmd)-1] == "down" {
-			executeHooks("post_down")
+	}
+	if _, skipped, err := builder.EnvFiles(); err == nil {
+		for _, file := range skipped {
+			plan.Notes = append(plan.Notes, fmt.Sprintf("skipped optional env file %s (not found)", file))
 		}
-		return nil
 	}
+	return plan, nil
+}
 
-	err = executor.RunInteractive(cmd)
+// executeHooks executes hooks for a given hook type
+func executeHooks(hookType string) error {
+	cfg, err := getConfig()
 	if err != nil {
 		return err
 	}
 
-	// Execute post hooks after successful command
-	if len(cmd) > 0 && cmd[len(cmd)-1] == "up" {
-		if err := executeHooks("post_up"); err != nil {
-			return err
-		}
+	steps, err := hookSteps(cfg, hookType)
+	if err != nil {
+		return err
+	}
+	plan := composepkg.NewPlan()
+	plan.Add(steps...)
+	if len(plan.Steps) == 0 {
+		return nil
 	}
 
-	if len(cmd) > 0 && cmd[len(cmd)-1] == "down" {
-		if err := executeHooks("post_down"); err != nil {
-			return err
-		}
+	if IsVerbose() {
+		fmt.Printf("Executing %s hooks...\n", hookType)
 	}
 
-	return nil
+	return runPlan(cfg, plan)
+}
+
+// parseHookCommand splits a hook into command arguments, honouring shell
+// quotes. Hooks run without a shell, so nothing is expanded.
+func parseHookCommand(hook string) ([]string, error) {
+	return composepkg.SplitWords(hook)
 }
 
-// executeCommands builds and executes multiple commands
-func executeCommands(buildFunc func(*Builder) ([][]string, error)) error {
-	builder, err := getComposeBuilder()
+// runPlan hands a plan to the runner configured from the global flags
+func runPlan(cfg *config.Config, plan *composepkg.Plan) error {
+	runner := composepkg.NewRunner(getComposeExecutor(cfg))
+	runner.Verbose = IsVerbose()
+	runner.Output = outputFormat
+	runner.Command = commandLine
+	runner.HistoryPath = project.GetHistoryPath()
+	return runner.Run(plan)
+}
+
+// executeCommand builds and executes a command with its pre and post hooks
+func executeCommand(name string, buildFunc func(*Builder, []string) ([]string, error), args []string) error {
+	cfg, err := getConfig()
+	if err != nil {
+		return err
+	}
+	builder, err := newComposeBuilder(cfg)
 	if err != nil {
 		return err
 	}
 
-	commands, err := buildFunc(builder)
+	args, err = validateServices(builder, name, args)
 	if err != nil {
 		return err
 	}
 
-	executor := getComposeExecutor()
+	cmd, err := buildFunc(builder, args)
+	if err != nil {
+		return err
+	}
 
-	// Set working directory
-	dir, _ := os.Getwd()
-	executor.SetDir(dir)
+	plan, err := newPlan(cfg, builder)
+	if err != nil {
+		return err
+	}
+	steps, err := composeSteps(cfg, builder.Verb(cmd), cmd, "command:"+name)
+	if err != nil {
+		return err
+	}
+	plan.Add(steps...)
+	return runPlan(cfg, plan)
+}
 
-	if IsDryRun() || IsVerbose() {
-		for _, cmd := range commands {
-			output := composepkg.FormatCommand(cmd)
-			printCommand(output)
-		}
+// executeCommands builds and executes multiple commands, each with its hooks
+func executeCommands(name string, buildFunc func(*Builder) ([][]string, error)) error {
+	cfg, err := getConfig()
+	if err != nil {
+		return err
+	}
+	builder, err := newComposeBuilder(cfg)
+	if err != nil {
+		return err
 	}
 
-	if IsDryRun() {
-		return nil
+	commands, err := buildFunc(builder)
+	if err != nil {
+		return err
 	}
 
-	err = executor.RunInteractiveMultiple(commands)
-	return err
+	plan, err := newPlan(cfg, builder)
+	if err != nil {
+		return err
+	}
+	for i, cmd := range commands {
+		steps, err := composeSteps(cfg, builder.Verb(cmd), cmd, fmt.Sprintf("command:%s[%d]", name, i))
+		if err != nil {
+			return err
+		}
+		plan.Add(steps...)
+	}
+	return runPlan(cfg, plan)
 }
 
 // printCommand prints a command in a formatted way
@@ -254,66 +413,121 @@ func resolveFile(file string) (string, error) {
 
 // resolveAlias resolves an alias definition into commands
 func resolveAlias(aliasDef string) ([][]string, error) {
+	cfg, err := getConfig()
+	if err != nil {
+		return nil, err
+	}
+	builder, err := newComposeBuilder(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	steps, err := resolveAliasSteps(builder, cfg, "", aliasDef)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := composepkg.NewPlan()
+	plan.Add(steps...)
+	return plan.Commands(), nil
+}
+
+// resolveAliasSteps resolves an alias definition into plan steps.
+// Commands are chained with &&; commands joined with a single & run in
+// parallel, and "wait DURATION" pauses between commands. Words are split
+// on shell quotes, so a quoted & is part of an argument. The pre hooks of
+// a parallel group run before it and its post hooks after it.
+// name identifies the alias in each step's source.
+func resolveAliasSteps(builder *Builder, cfg *config.Config, name, aliasDef string) ([]composepkg.Step, error) {
 	if aliasDef == "" {
 		return nil, fmt.Errorf("empty alias definition")
 	}
 
-	// Split by && to find chained commands
-	parts := strings.Split(aliasDef, "&&")
-	commands := make([][]string, 0, len(parts))
-
-	builder, err := getComposeBuilder()
+	chain, err := composepkg.SplitChain(aliasDef)
 	if err != nil {
 		return nil, err
 	}
+	steps := make([]composepkg.Step, 0, len(chain))
 
-	for _, part := range parts {
-		cmd := strings.Fields(strings.TrimSpace(part))
-		if len(cmd) == 0 {
-			continue
-		}
+	for _, members := range chain {
+		source := fmt.Sprintf("aliases.%s[%d]", name, len(steps))
 
-		// Check if this is a known command
-		if isKnownCommand(cmd[0]) {
-			// Build the appropriate docker compose command
-			var fullCmd []string
-			switch cmd[0] {
-			case "up":
-				fullCmd, _ = builder.BuildUp(cmd[1:])
-			case "down":
-				fullCmd, _ = builder.BuildDown(cmd[1:])
-			case "ps":
-				fullCmd, _ = builder.BuildPs(cmd[1:])
-			case "logs":
-				fullCmd, _ = builder.BuildLogs(cmd[1:])
-			case "restart":
-				fullCmd, _ = builder.BuildRestart(cmd[1:])
-			case "exec":
-				fullCmd, _ = builder.BuildExec(cmd[1:])
-			case "build":
-				fullCmd, _ = builder.BuildBuild(cmd[1:])
-			default:
-				// Unknown command, pass through as-is
-				fullCmd = []string{"docker", "compose"}
-				fullCmd = append(fullCmd, cmd...)
+		var pre, group, post []composepkg.Step
+		for _, cmd := range members {
+			memberPre, step, memberPost, err := aliasCommandSteps(builder, cfg, cmd, source)
+			if err != nil {
+				return nil, err
 			}
-			commands = append(commands, fullCmd)
+			pre = append(pre, memberPre...)
+			group = append(group, step)
+			post = append(post, memberPost...)
+		}
+
+		steps = append(steps, pre...)
+		if len(group) > 1 {
+			steps = append(steps, composepkg.ParallelStep(source, group...))
 		} else {
-			// Pass through command (could be a shell command)
-			commands = append(commands, cmd)
+			steps = append(steps, group...)
 		}
+		steps = append(steps, post...)
+	}
+
+	return steps, nil
+}
+
+// aliasCommandSteps resolves a single command of an alias into its step and
+// the pre and post hooks around it. Service arguments of compose commands
+// are validated like those of the matching dox command.
+func aliasCommandSteps(builder *Builder, cfg *config.Config, cmd []string, source string) (pre []composepkg.Step, step composepkg.Step, post []composepkg.Step, err error) {
+	if cmd[0] == "wait" && len(cmd) == 2 {
+		step, err = composepkg.WaitStep(cmd[1], source)
+		return nil, step, nil, err
+	}
+
+	// Pass through unknown commands (could be a shell command)
+	if !isKnownCommand(cmd[0]) {
+		return nil, newStep(cfg, composepkg.StepShell, cmd, source), nil, nil
+	}
+
+	args, err := validateServices(builder, cmd[0], cmd[1:])
+	if err != nil {
+		return nil, step, nil, fmt.Errorf("%s: %w", source, err)
 	}
 
-	return commands, nil
+	// Build the appropriate docker compose command
+	var fullCmd []string
+	switch cmd[0] {
+	case "up":
+		fullCmd, err = builder.BuildUp(args)
+	case "down":
+		fullCmd, err = builder.BuildDown(args)
+	case "ps":
+		fullCmd, err = builder.BuildPs(args)
+	case "logs":
+		fullCmd, err = builder.BuildLogs(args)
+	case "restart":
+		fullCmd, err = builder.BuildRestart(args)
+	case "exec":
+		fullCmd, err = builder.BuildExec(args)
+	case "build":
+		fullCmd, err = builder.BuildBuild(args)
+	default:
+		fullCmd, err = builder.Build(cmd[0], args)
+	}
+	if err != nil {
+		return nil, step, nil, fmt.Errorf("%s: %w", source, err)
+	}
+
+	if pre, err = hookSteps(cfg, "pre_"+cmd[0]); err != nil {
+		return nil, step, nil, err
+	}
+	if post, err = hookSteps(cfg, "post_"+cmd[0]); err != nil {
+		return nil, step, nil, err
+	}
+	return pre, newStep(cfg, composepkg.StepCompose, fullCmd, source), post, nil
 }
 
 // isKnownCommand checks if a command word is a known docker compose subcommand
 func isKnownCommand(cmd string) bool {
-	knownCommands := []string{
-		"up", "down", "ps", "logs", "restart", "exec", "build",
-		"pull", "push", "start", "stop", "rm", "kill", "run",
-		"pause", "unpause", "top", "events", "port", "config",
-		"create", "version",
-	}
-	return slices.Contains(knownCommands, cmd)
+	return slices.Contains(composepkg.Commands, cmd)
 }
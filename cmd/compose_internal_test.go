@@ -1,10 +1,13 @@
 package commands
 
 import (
+	"encoding/json"
 	"os"
 	"path/filepath"
 	"testing"
 
+	composepkg "github.com/AkaraChen/dox/internal/compose"
+	"github.com/AkaraChen/dox/internal/config"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -85,7 +88,7 @@ func TestGetConfig_WithDoYaml(t *testing.T) {
 }
 
 func TestGetComposeExecutor(t *testing.T) {
-	executor := getComposeExecutor()
+	executor := getComposeExecutor(nil)
 	assert.NotNil(t, executor)
 }
 
@@ -94,7 +97,7 @@ func TestGetComposeExecutor_WithDryRun(t *testing.T) {
 	defer func() { dryRun = originalDryRun }()
 
 	dryRun = true
-	executor := getComposeExecutor()
+	executor := getComposeExecutor(nil)
 	assert.NotNil(t, executor)
 }
 
@@ -103,7 +106,7 @@ func TestGetComposeExecutor_WithVerbose(t *testing.T) {
 	defer func() { verbose = originalVerbose }()
 
 	verbose = true
-	executor := getComposeExecutor()
+	executor := getComposeExecutor(nil)
 	assert.NotNil(t, executor)
 	assert.Contains(t, executor.Env, "DOCKER_COMPOSE_VERBOSE=1")
 }
@@ -134,14 +137,28 @@ func TestParseHookCommand(t *testing.T) {
 			hook:     "restart",
 			expected: []string{"restart"},
 		},
+		{
+			name:     "quoted argument",
+			hook:     `echo "Starting services..."`,
+			expected: []string{"echo", "Starting services..."},
+		},
+		{
+			name:     "single quotes",
+			hook:     "echo 'Pre down hook'",
+			expected: []string{"echo", "Pre down hook"},
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := parseHookCommand(tt.hook)
+			result, err := parseHookCommand(tt.hook)
+			require.NoError(t, err)
 			assert.Equal(t, tt.expected, result)
 		})
 	}
+
+	_, err := parseHookCommand(`echo "unterminated`)
+	assert.Error(t, err)
 }
 
 func TestPrintCommand(t *testing.T) {
@@ -330,7 +347,7 @@ func TestResolveAlias_MultipleKnownCommands(t *testing.T) {
 	err := os.Chdir(fixtureDir)
 	require.NoError(t, err)
 
-	commands, err := resolveAlias("ps && logs && restart")
+	commands, err := resolveAlias("ps && logs && restart web")
 	assert.NoError(t, err)
 	assert.Len(t, commands, 3)
 }
@@ -347,3 +364,179 @@ func TestResolveAlias_MixedCommands(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Len(t, commands, 3)
 }
+
+func TestExecuteCommand_DryRunJSONPlan(t *testing.T) {
+	fixtureDir := filepath.Join("..", "test", "fixtures", "with-hooks")
+	originalDir, _ := os.Getwd()
+	defer os.Chdir(originalDir)
+
+	err := os.Chdir(fixtureDir)
+	require.NoError(t, err)
+
+	dryRun = true
+	outputFormat = composepkg.OutputJSON
+	defer func() {
+		dryRun = false
+		outputFormat = composepkg.OutputText
+	}()
+
+	original := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	err = executeCommand("up", func(b *Builder, a []string) ([]string, error) {
+		return b.BuildUp(a)
+	}, nil)
+
+	w.Close()
+	os.Stdout = original
+	require.NoError(t, err)
+
+	var plan composepkg.Plan
+	require.NoError(t, json.NewDecoder(r).Decode(&plan))
+	require.Len(t, plan.Steps, 5)
+	assert.Equal(t, composepkg.StepHook, plan.Steps[0].Type)
+	assert.Equal(t, "hooks.pre_up[0]", plan.Steps[0].Source)
+	assert.Equal(t, []string{"echo", "Starting services..."}, plan.Steps[0].Argv)
+	assert.Equal(t, composepkg.StepCompose, plan.Steps[2].Type)
+	assert.Equal(t, "command:up", plan.Steps[2].Source)
+	assert.Equal(t, "hooks.post_up[1]", plan.Steps[4].Source)
+}
+
+func TestExecuteCommands_RunsHooksPerCommand(t *testing.T) {
+	fixtureDir := filepath.Join("..", "test", "fixtures", "with-hooks")
+	originalDir, _ := os.Getwd()
+	defer os.Chdir(originalDir)
+
+	err := os.Chdir(fixtureDir)
+	require.NoError(t, err)
+
+	dryRun = true
+	outputFormat = composepkg.OutputJSON
+	defer func() {
+		dryRun = false
+		outputFormat = composepkg.OutputText
+	}()
+
+	original := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	err = executeCommands("dup", func(b *Builder) ([][]string, error) {
+		return b.BuildDup()
+	})
+
+	w.Close()
+	os.Stdout = original
+	require.NoError(t, err)
+
+	var plan composepkg.Plan
+	require.NoError(t, json.NewDecoder(r).Decode(&plan))
+
+	sources := make([]string, 0, len(plan.Steps))
+	for _, step := range plan.Steps {
+		sources = append(sources, step.Source)
+	}
+	assert.Equal(t, []string{
+		"hooks.pre_down[0]",
+		"command:dup[0]",
+		"hooks.pre_up[0]",
+		"hooks.pre_up[1]",
+		"command:dup[1]",
+		"hooks.post_up[0]",
+		"hooks.post_up[1]",
+	}, sources)
+}
+
+func TestResolveAliasSteps_ParallelAndWait(t *testing.T) {
+	fixtureDir := filepath.Join("..", "test", "fixtures", "simple")
+	originalDir, _ := os.Getwd()
+	defer os.Chdir(originalDir)
+
+	err := os.Chdir(fixtureDir)
+	require.NoError(t, err)
+
+	builder, err := getComposeBuilder()
+	require.NoError(t, err)
+
+	steps, err := resolveAliasSteps(builder, nil, "warm", "up -d db & up -d cache && wait 5s && logs")
+	require.NoError(t, err)
+	require.Len(t, steps, 3)
+	assert.Equal(t, composepkg.StepParallel, steps[0].Type)
+	assert.Len(t, steps[0].Steps, 2)
+	assert.Equal(t, composepkg.StepWait, steps[1].Type)
+	assert.Equal(t, "5s", steps[1].Duration)
+	assert.Equal(t, "aliases.warm[2]", steps[2].Source)
+
+	_, err = resolveAliasSteps(builder, nil, "bad", "wait forever")
+	assert.Error(t, err)
+
+	steps, err = resolveAliasSteps(builder, nil, "seed", `curl -d "a=1&b=2" && echo 'x && y'`)
+	require.NoError(t, err)
+	require.Len(t, steps, 2)
+	assert.Equal(t, []string{"curl", "-d", "a=1&b=2"}, steps[0].Argv)
+	assert.Equal(t, []string{"echo", "x && y"}, steps[1].Argv)
+
+	_, err = resolveAliasSteps(builder, nil, "shell", "down && exec")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "aliases.shell[1]: exec requires at least a service name")
+}
+
+func TestResolveAliasSteps_ParallelHooks(t *testing.T) {
+	chdirFixture(t, "with-hooks")
+	cfg, err := getConfig()
+	require.NoError(t, err)
+	builder, err := newComposeBuilder(cfg)
+	require.NoError(t, err)
+
+	// Hooks run around the parallel group, not inside it
+	steps, err := resolveAliasSteps(builder, cfg, "both", "up -d & logs -f")
+	require.NoError(t, err)
+	sources := make([]string, 0, len(steps))
+	for _, step := range steps {
+		sources = append(sources, step.Source)
+	}
+	assert.Equal(t, []string{
+		"hooks.pre_up[0]",
+		"hooks.pre_up[1]",
+		"aliases.both[0]",
+		"hooks.post_up[0]",
+		"hooks.post_up[1]",
+	}, sources)
+	require.Equal(t, composepkg.StepParallel, steps[2].Type)
+	for _, step := range steps[2].Steps {
+		assert.Equal(t, composepkg.StepCompose, step.Type)
+	}
+}
+
+func TestResolveAliasSteps_ValidatesServices(t *testing.T) {
+	chdirFixture(t, "services")
+	builder, err := getComposeBuilder()
+	require.NoError(t, err)
+
+	steps, err := resolveAliasSteps(builder, nil, "tail", "logs -f worker-*")
+	require.NoError(t, err)
+	require.Len(t, steps, 1)
+	assert.Equal(t, []string{"worker-email", "worker-reports"}, steps[0].Argv[len(steps[0].Argv)-2:])
+
+	_, err = resolveAliasSteps(builder, nil, "tail", "up -d && logs apii")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "aliases.tail[1]: no such service 'apii'")
+}
+
+func TestResolveRuntime_EnvOverridesConfig(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("DOX_RUNTIME", "podman")
+
+	rt, err := resolveRuntime(&config.Config{Runtime: "nerdctl"})
+	require.NoError(t, err)
+	assert.Equal(t, "podman", rt.Name())
+
+	t.Setenv("DOX_RUNTIME", "")
+	rt, err = resolveRuntime(&config.Config{Runtime: "nerdctl"})
+	require.NoError(t, err)
+	assert.Equal(t, "nerdctl", rt.Name())
+
+	_, err = resolveRuntime(&config.Config{Runtime: "rkt"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,265 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/AkaraChen/dox/internal/config"
+	"github.com/AkaraChen/dox/internal/schema"
+	"github.com/spf13/cobra"
+)
+
+// configCmd represents the config command group
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect dox.yaml",
+}
+
+// configShowCmd represents the config show command
+var configShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Show the effective settings of a profile",
+	Long: `Show the settings of a profile after inheritance through extends.
+
+Each value is followed by where it was set: the profile that defines it or
+the top level of dox.yaml. Lists are concatenated along the extends chain,
+maps are merged and scalars are overridden; a profile can stop inheriting a
+field by listing it under reset.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runConfigShow(os.Stdout)
+	},
+}
+
+// configMigrateCmd represents the config migrate command
+var configMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Rewrite dox.yaml to the current config version",
+	Long: `Rewrite dox.yaml in place to version 2, keeping its comments.
+
+defaults.profile moves to default_profile, the env of each profile is
+replaced by the env_file that the top-level env_files gave it, and the
+discovered slices are listed under slices. Files that dox.yaml includes and
+local override files are not changed. Use --dry-run to print the result
+instead of writing it.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runConfigMigrate(os.Stdout)
+	},
+}
+
+// configSchemaGlobal selects the schema of the global config
+var configSchemaGlobal bool
+
+// configSchemaCmd represents the config schema command
+var configSchemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Print the JSON Schema of dox.yaml",
+	Long: `Print the JSON Schema of dox.yaml, or with --global of the global config in
+~/.config/dox/config.yaml.
+
+Editors use it for completion and validation, for example through a comment
+at the top of dox.yaml that the YAML language server reads:
+
+  # yaml-language-server: $schema=https://raw.githubusercontent.com/AkaraChen/dox/main/schema/dox.schema.json
+
+CI can validate dox.yaml against it with any JSON Schema validator.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runConfigSchema(os.Stdout, configSchemaGlobal)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configShowCmd)
+	configCmd.AddCommand(configMigrateCmd)
+	configCmd.AddCommand(configSchemaCmd)
+	configSchemaCmd.Flags().BoolVar(&configSchemaGlobal, "global", false, "print the schema of the global config")
+	configShowCmd.Flags().StringVarP(&profile, "profile", "p", "", "profile to show from dox.yaml")
+	configShowCmd.RegisterFlagCompletionFunc("profile", completeProfiles)
+}
+
+// runConfigShow writes the effective settings with their provenance
+func runConfigShow(w io.Writer) error {
+	dir, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	cfg, err := loadConfigIn(dir)
+	if err != nil {
+		return err
+	}
+	if cfg == nil {
+		return &config.MissingFileError{Path: "dox.yaml", Message: "no dox.yaml found"}
+	}
+
+	if len(cfg.Files) > 1 {
+		fmt.Fprintf(w, "# merged from: %s\n", strings.Join(relativePaths(dir, cfg.Files), ", "))
+	}
+	for _, file := range cfg.Overrides {
+		fmt.Fprintf(w, "# local override active: %s\n", relativePaths(dir, []string{file})[0])
+	}
+
+	name, source := selectedProfile(cfg)
+	if name == "" {
+		fmt.Fprintln(w, "# no profile selected, showing the top level of dox.yaml")
+		writeHooks(w, cfg.Hooks, func(string) string { return "dox.yaml" })
+		writeMap(w, config.FieldAliases, cfg.Aliases, func(string) string { return "dox.yaml" })
+		return nil
+	}
+
+	discovery, err := config.DiscoverFiles(dir)
+	if err != nil {
+		return err
+	}
+	trace, err := cfg.TraceProfile(name, discovery)
+	if err != nil {
+		return err
+	}
+	effective, err := cfg.EffectiveProfile(name)
+	if err != nil {
+		return err
+	}
+
+	origin := func(key string) string {
+		return provenance(effective.Sources[key])
+	}
+
+	fmt.Fprintf(w, "profile: %s  # %s\n", name, source)
+	if len(effective.Order) > 1 {
+		fmt.Fprintf(w, "# inheritance order: %s\n", strings.Join(effective.Order, ", "))
+	}
+
+	fmt.Fprintf(w, "%s:\n", config.FieldSlices)
+	for _, slice := range trace.Slices {
+		if slice.Duplicate || slice.Excluded || slice.DroppedBy != "" {
+			continue
+		}
+		fmt.Fprintf(w, "  - %s  # %s\n", slice.Name, provenance(slice.Profile))
+	}
+
+	for _, scalar := range []struct{ field, value string }{
+		{config.FieldEnvFile, effective.EnvFile},
+		{config.FieldProjectName, effective.ProjectName},
+		{config.FieldContext, effective.Context},
+	} {
+		if scalar.value != "" {
+			fmt.Fprintf(w, "%s: %s  # %s\n", scalar.field, scalar.value, origin(scalar.field))
+		}
+	}
+
+	if len(effective.EnvFiles) > 0 {
+		fmt.Fprintf(w, "%s:\n", config.FieldEnvFiles)
+		for i, entry := range effective.EnvFiles {
+			fmt.Fprintf(w, "  - %s  # %s\n", entry, origin(fmt.Sprintf("env_files[%d]", i)))
+		}
+	}
+	writeMap(w, config.FieldEnvironment, effective.Environment, func(key string) string {
+		return origin(config.FieldEnvironment + "." + key)
+	})
+	writeHooks(w, effective.Hooks, origin)
+	writeMap(w, config.FieldAliases, effective.Aliases, func(key string) string {
+		return origin(config.FieldAliases + "." + key)
+	})
+	return nil
+}
+
+// runConfigMigrate rewrites dox.yaml to the current version and lists the
+// changes
+func runConfigMigrate(w io.Writer) error {
+	dir, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	path, err := config.FindConfigFile(dir)
+	if err != nil {
+		return &config.MissingFileError{Path: "dox.yaml", Message: "no dox.yaml found"}
+	}
+
+	data, changes, err := config.Migrate(path)
+	if err != nil {
+		return err
+	}
+	if len(changes) == 0 {
+		fmt.Fprintf(w, "dox.yaml is already version %d\n", config.CurrentVersion)
+		return nil
+	}
+	if IsDryRun() {
+		_, err := w.Write(data)
+		return err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, info.Mode().Perm()); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "Migrated dox.yaml to version %d:\n", config.CurrentVersion)
+	for _, change := range changes {
+		fmt.Fprintf(w, "  %s\n", change)
+	}
+	return nil
+}
+
+// runConfigSchema writes the JSON Schema of dox.yaml or the global config
+func runConfigSchema(w io.Writer, global bool) error {
+	generate := schema.Config
+	if global {
+		generate = schema.Global
+	}
+	s, err := generate()
+	if err != nil {
+		return err
+	}
+	return schema.Write(w, s)
+}
+
+// writeHooks writes hooks by type, in the order they run
+func writeHooks(w io.Writer, hooks map[string][]string, origin func(key string) string) {
+	if len(hooks) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "%s:\n", config.FieldHooks)
+	for _, hookType := range sortedMapKeys(hooks) {
+		fmt.Fprintf(w, "  %s:\n", hookType)
+		for i, hook := range hooks[hookType] {
+			fmt.Fprintf(w, "    - %s  # %s\n", hook, origin(fmt.Sprintf("hooks.%s[%d]", hookType, i)))
+		}
+	}
+}
+
+// writeMap writes a string map sorted by key
+func writeMap(w io.Writer, field string, values map[string]string, origin func(key string) string) {
+	if len(values) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "%s:\n", field)
+	for _, key := range sortedMapKeys(values) {
+		fmt.Fprintf(w, "  %s: %s  # %s\n", key, values[key], origin(key))
+	}
+}
+
+// provenance describes the source of a value: a profile, or "" for the top
+// level of dox.yaml
+func provenance(source string) string {
+	if source == "" {
+		return "dox.yaml"
+	}
+	return "profile " + source
+}
+
+func sortedMapKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,166 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunConfigShow(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"compose.yaml", "compose.db.yaml", "compose.prod.yaml"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte("services: {}\n"), 0644))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "dox.yaml"), []byte(`
+version: 1
+hooks:
+  pre_up: ["echo top"]
+profiles:
+  base:
+    slices: [db]
+    project_name: shop
+    environment:
+      REGION: eu
+  prod:
+    extends: base
+    slices: [prod]
+    env_file: .env.prod
+`), 0644))
+
+	original, _ := os.Getwd()
+	require.NoError(t, os.Chdir(dir))
+	defer os.Chdir(original)
+
+	profile = "prod"
+	defer func() { profile = "" }()
+
+	var out bytes.Buffer
+	require.NoError(t, runConfigShow(&out))
+	assert.Equal(t, `profile: prod  # --profile flag
+# inheritance order: base, prod
+slices:
+  - db  # profile base
+  - prod  # profile prod
+env_file: .env.prod  # profile prod
+project_name: shop  # profile base
+environment:
+  REGION: eu  # profile base
+hooks:
+  pre_up:
+    - echo top  # dox.yaml
+`, out.String())
+}
+
+func TestRunConfigShow_Include(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "team.yaml"), []byte("aliases:\n  l: logs -f\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "dox.yaml"), []byte("include: team.yaml\n"), 0644))
+
+	original, _ := os.Getwd()
+	require.NoError(t, os.Chdir(dir))
+	defer os.Chdir(original)
+
+	var out bytes.Buffer
+	require.NoError(t, runConfigShow(&out))
+	assert.Equal(t, `# merged from: team.yaml, dox.yaml
+# no profile selected, showing the top level of dox.yaml
+aliases:
+  l: logs -f  # dox.yaml
+`, out.String())
+}
+
+func TestRunConfigShow_LocalOverride(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "dox.yaml"), []byte("aliases:\n  l: logs\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "dox.local.yaml"), []byte("aliases:\n  l: logs -f\n"), 0644))
+
+	original, _ := os.Getwd()
+	require.NoError(t, os.Chdir(dir))
+	defer os.Chdir(original)
+
+	var out bytes.Buffer
+	require.NoError(t, runConfigShow(&out))
+	assert.Equal(t, `# merged from: dox.yaml, dox.local.yaml
+# local override active: dox.local.yaml
+# no profile selected, showing the top level of dox.yaml
+aliases:
+  l: logs -f  # dox.yaml
+`, out.String())
+}
+
+func TestRunConfigMigrate(t *testing.T) {
+	dir := chdirTemp(t, "compose.yaml", "compose.dev.yaml")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "dox.yaml"), []byte(`# Shop
+defaults:
+  profile: dev
+profiles:
+  dev:
+    slices: [dev]
+`), 0644))
+
+	var out bytes.Buffer
+	warningOutput = &out
+	configWarned = false
+	defer func() { warningOutput = os.Stderr }()
+	_, err := getConfig()
+	require.NoError(t, err)
+	assert.Equal(t, `Warning: dox.yaml: defaults.profile is deprecated; use default_profile
+Run 'dox config migrate' to update dox.yaml
+`, out.String())
+
+	// A command loading the config again does not repeat the warnings
+	out.Reset()
+	_, err = getConfig()
+	require.NoError(t, err)
+	assert.Empty(t, out.String())
+
+	out.Reset()
+	require.NoError(t, runConfigMigrate(&out))
+	assert.Equal(t, `Migrated dox.yaml to version 2:
+  set version: 2
+  moved defaults.profile to default_profile
+  removed defaults
+  listed the discovered slices under slices: dev
+`, out.String())
+
+	data, err := os.ReadFile(filepath.Join(dir, "dox.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, `# Shop
+version: 2
+default_profile: dev
+slices:
+  dev: [compose.dev.yaml]
+profiles:
+  dev:
+    slices: [dev]
+`, string(data))
+
+	out.Reset()
+	configWarned = false
+	_, err = getConfig()
+	require.NoError(t, err)
+	assert.Empty(t, out.String())
+
+	require.NoError(t, runConfigMigrate(&out))
+	assert.Equal(t, "dox.yaml is already version 2\n", out.String())
+}
+
+func TestRunConfigSchema(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, runConfigSchema(&out, false))
+	var s map[string]any
+	require.NoError(t, json.Unmarshal(out.Bytes(), &s))
+	assert.Equal(t, "dox.yaml", s["title"])
+	assert.Contains(t, s["properties"], "profiles")
+
+	out.Reset()
+	require.NoError(t, runConfigSchema(&out, true))
+	require.NoError(t, json.Unmarshal(out.Bytes(), &s))
+	assert.Equal(t, "dox global config", s["title"])
+	assert.Contains(t, s["properties"], "projects")
+}
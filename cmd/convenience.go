@@ -14,7 +14,7 @@ Equivalent to running 'down' followed by 'up'.`,
 	Args: cobra.ArbitraryArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
 	 // dup doesn't pass args through - it's a fixed operation
-	 return executeCommands(func(b *Builder) ([][]string, error) {
+	 return executeCommands("dup", func(b *Builder) ([][]string, error) {
    return b.BuildDup()
 	 })
 	},
@@ -29,7 +29,7 @@ var nukeCmd = &cobra.Command{
 Equivalent to 'docker compose down -v --remove-orphans'. Use with caution!`,
 	Args: cobra.ArbitraryArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
-	 return executeCommands(func(b *Builder) ([][]string, error) {
+	 return executeCommands("nuke", func(b *Builder) ([][]string, error) {
    return b.BuildNuke()
 	 })
 	},
@@ -44,7 +44,7 @@ var freshCmd = &cobra.Command{
 Equivalent to 'down -v' followed by 'up --build'.`,
 	Args: cobra.ArbitraryArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
-	 return executeCommands(func(b *Builder) ([][]string, error) {
+	 return executeCommands("fresh", func(b *Builder) ([][]string, error) {
    return b.BuildFresh()
 	 })
 	},
@@ -0,0 +1,75 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/AkaraChen/dox/internal/ansi"
+	composepkg "github.com/AkaraChen/dox/internal/compose"
+	"github.com/AkaraChen/dox/internal/model"
+	"github.com/spf13/cobra"
+)
+
+var diffJSON bool
+
+// diffCmd represents the diff command
+var diffCmd = &cobra.Command{
+	Use:   "diff PROFILE PROFILE",
+	Short: "Compare the effective compose configuration of two profiles",
+	Long: `Compare the merged compose files of two profiles from dox.yaml.
+
+Lists services that only one profile defines and, per service, differences
+in image, command, entrypoint, replicas, environment variables, ports and
+volumes. Output is colored on terminals; use --json for a structured diff.`,
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: completeProfileArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runDiff(args[0], args[1])
+	},
+}
+
+func init() {
+	composeGroupCmd.AddCommand(diffCmd)
+	diffCmd.Flags().BoolVar(&diffJSON, "json", false, "print the diff as JSON")
+}
+
+// runDiff prints the semantic diff between two profiles
+func runDiff(from, to string) error {
+	dir, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	cfg, err := getConfig()
+	if err != nil {
+		return err
+	}
+	if cfg == nil {
+		return fmt.Errorf("diff requires a dox.yaml with profiles")
+	}
+
+	projects := make([]*model.Project, 2)
+	for i, name := range []string{from, to} {
+		files, err := composepkg.NewBuilder(dir, cfg, name).Files()
+		if err != nil {
+			return err
+		}
+		if projects[i], err = model.Load(files...); err != nil {
+			return fmt.Errorf("profile '%s': %w", name, err)
+		}
+	}
+
+	diff := model.DiffProjects(from, to, projects[0], projects[1])
+	if diffJSON {
+		return diff.WriteJSON(os.Stdout)
+	}
+	diff.WriteText(os.Stdout, ansi.Enabled(os.Stdout))
+	return nil
+}
+
+// completeProfileArgs completes positional profile names
+func completeProfileArgs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if cmd.Args != nil && cmd.Args(cmd, append(args, toComplete)) != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return completeProfiles(cmd, args, toComplete)
+}
@@ -0,0 +1,35 @@
+package commands
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/AkaraChen/dox/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunDiff_JSON(t *testing.T) {
+	chdirFixture(t, "with-profiles")
+	diffJSON = true
+	defer func() { diffJSON = false }()
+
+	original := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	err := runDiff("dev", "prod")
+
+	w.Close()
+	os.Stdout = original
+	require.NoError(t, err)
+
+	var diff model.ProjectDiff
+	require.NoError(t, json.NewDecoder(r).Decode(&diff))
+	require.Len(t, diff.Services, 1)
+	development, production := "development", "production"
+	assert.Contains(t, diff.Services[0].Changes, model.FieldChange{Field: "environment.NODE_ENV", Old: &development, New: &production})
+
+	assert.Error(t, runDiff("dev", "missing"))
+}
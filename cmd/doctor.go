@@ -0,0 +1,63 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/AkaraChen/dox/internal/doctor"
+	"github.com/spf13/cobra"
+)
+
+var doctorJSON bool
+
+// doctorCmd represents the doctor command
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check the environment and project health",
+	Long: `Check that dox can work in the current directory.
+
+Runs a series of checks and prints a pass/warn/fail report with fix hints:
+the container runtime and its version, daemon reachability, dox.yaml
+validity, the resolved compose files (via 'compose config -q'), env files,
+the global config and its project paths, and the history file.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runDoctor()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+	doctorCmd.Flags().BoolVar(&doctorJSON, "json", false, "print the report as JSON")
+	doctorCmd.Flags().StringVarP(&profile, "profile", "p", "", "profile to check from dox.yaml")
+	doctorCmd.RegisterFlagCompletionFunc("profile", completeProfiles)
+}
+
+// runDoctor runs all health checks for the current directory
+func runDoctor() error {
+	dir, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	// Config and runtime errors are reported by the checks themselves
+	cfg, _ := getConfig()
+	rt, rtErr := resolveRuntime(cfg)
+	profileName, _ := selectedProfile(cfg)
+
+	env := doctor.NewEnv(dir, profileName, rt)
+	env.RuntimeErr = rtErr
+	report := doctor.Run(env)
+	if doctorJSON {
+		if err := report.WriteJSON(os.Stdout); err != nil {
+			return err
+		}
+	} else {
+		report.WriteText(os.Stdout)
+	}
+
+	if report.Failed() {
+		return fmt.Errorf("doctor found %d failing checks", report.Counts()[doctor.StatusFail])
+	}
+	return nil
+}
@@ -13,7 +13,7 @@ var downCmd = &cobra.Command{
 Supports standard docker compose flags like -v (remove volumes) and --remove-orphans.`,
 	Args: cobra.ArbitraryArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
-	 return executeCommand(func(b *Builder, a []string) ([]string, error) {
+	 return executeCommand("down", func(b *Builder, a []string) ([]string, error) {
    return b.BuildDown(a)
 	 }, args)
 	},
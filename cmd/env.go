@@ -0,0 +1,236 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/AkaraChen/dox/internal/ansi"
+	composepkg "github.com/AkaraChen/dox/internal/compose"
+	"github.com/AkaraChen/dox/internal/config"
+	"github.com/AkaraChen/dox/internal/environ"
+	"github.com/spf13/cobra"
+)
+
+var (
+	envReveal bool
+	envJSON   bool
+)
+
+// envCmd represents the env command
+var envCmd = &cobra.Command{
+	Use:   "env",
+	Short: "Show the environment compose will see",
+	Long: `Show the variables compose uses for interpolation with the selected profile.
+
+Variables are resolved as compose does: the profile's env files in order,
+then the shell environment, which compose prefers over env files, then the
+environment of the @project registry entry and the profile, which dox
+exports. Only shell variables that an env file sets or a compose file
+references are listed.
+
+Each variable is followed by where it was set and the sources it overrides.
+Values that look like secrets are masked unless --reveal is given. Variables
+that the compose files reference but nothing sets are listed at the end.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runEnv(os.Stdout)
+	},
+}
+
+// envDiffCmd represents the env diff command
+var envDiffCmd = &cobra.Command{
+	Use:   "diff PROFILE PROFILE",
+	Short: "Compare the environment of two profiles",
+	Long: `Compare the variables compose sees with two profiles from dox.yaml.
+
+Lists variables that only one profile sets and variables whose values
+differ. Values that look like secrets are masked unless --reveal is given.`,
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: completeProfileArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runEnvDiff(os.Stdout, args[0], args[1])
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(envCmd)
+	envCmd.AddCommand(envDiffCmd)
+	envCmd.Flags().StringVarP(&profile, "profile", "p", "", "profile to use from dox.yaml")
+	envCmd.RegisterFlagCompletionFunc("profile", completeProfiles)
+	envCmd.PersistentFlags().BoolVar(&envReveal, "reveal", false, "print secret values instead of masking them")
+	envCmd.PersistentFlags().BoolVar(&envJSON, "json", false, "print the environment as JSON")
+}
+
+// envReport is the JSON form of dox env
+type envReport struct {
+	Profile         string              `json:"profile,omitempty"`
+	Variables       []*environ.Var      `json:"variables"`
+	MissingEnvFiles []string            `json:"missing_env_files,omitempty"`
+	Unset           []environ.Reference `json:"unset,omitempty"`
+}
+
+// runEnv prints the resolved environment of the selected profile
+func runEnv(w io.Writer) error {
+	dir, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	cfg, err := getConfig()
+	if err != nil {
+		return err
+	}
+
+	name, source := selectedProfile(cfg)
+	env, refs, err := resolveEnvironment(dir, cfg, name)
+	if err != nil {
+		return err
+	}
+	unset := env.Unset(refs)
+
+	if envJSON {
+		report := envReport{Profile: name, MissingEnvFiles: env.Missing, Unset: unset}
+		for _, v := range env.Sorted() {
+			report.Variables = append(report.Variables, v.Masked(envReveal))
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+
+	if name != "" {
+		fmt.Fprintf(w, "# profile %s (%s)\n", name, source)
+	}
+	for _, file := range env.Missing {
+		fmt.Fprintf(w, "# env file %s not found\n", file)
+	}
+	for _, v := range env.Sorted() {
+		line := fmt.Sprintf("%s=%s  # %s", v.Key, v.Display(envReveal), v.Source)
+		if len(v.Overrides) > 0 {
+			line += fmt.Sprintf(" (overrides %s)", strings.Join(v.Overrides, ", "))
+		}
+		fmt.Fprintln(w, line)
+	}
+
+	if len(unset) > 0 {
+		fmt.Fprintln(w, "\nUnset variables referenced in compose files:")
+		for _, ref := range unset {
+			note := ""
+			switch {
+			case ref.Required:
+				note = " (required)"
+			case ref.HasDefault:
+				note = " (has a default)"
+			}
+			fmt.Fprintf(w, "  %s  # %s%s\n", ref.Name, strings.Join(relativePaths(dir, ref.Files), ", "), note)
+		}
+	}
+	return nil
+}
+
+// runEnvDiff prints the difference between the environments of two profiles
+func runEnvDiff(w io.Writer, from, to string) error {
+	dir, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	cfg, err := getConfig()
+	if err != nil {
+		return err
+	}
+	if cfg == nil {
+		return fmt.Errorf("env diff requires a dox.yaml with profiles")
+	}
+
+	envs := make([]*environ.Environment, 2)
+	for i, name := range []string{from, to} {
+		if envs[i], _, err = resolveEnvironment(dir, cfg, name); err != nil {
+			return err
+		}
+	}
+
+	diff := environ.DiffEnvironments(from, to, envs[0], envs[1])
+	if envJSON {
+		return diff.WriteJSON(w, envReveal)
+	}
+	diff.WriteText(w, ansi.Enabled(w), envReveal)
+	return nil
+}
+
+// resolveEnvironment computes the variables compose sees with a profile and
+// the variables its compose files reference
+func resolveEnvironment(dir string, cfg *config.Config, name string) (*environ.Environment, []environ.Reference, error) {
+	builder := composepkg.NewBuilder(dir, cfg, name)
+	files, err := builder.Files()
+	if err != nil {
+		return nil, nil, err
+	}
+	refs, err := environ.References(files)
+	if err != nil {
+		return nil, nil, err
+	}
+	envFiles, _, err := builder.EnvFiles()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	env := environ.New()
+	for _, envFile := range envFiles {
+		path := envFile
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+		if _, err := os.Stat(path); err != nil {
+			env.Missing = append(env.Missing, envFile)
+			continue
+		}
+		if err := env.LoadFile(path, envFile); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	shellKeys := map[string]bool{}
+	for key := range env.Vars {
+		shellKeys[key] = true
+	}
+	for _, ref := range refs {
+		shellKeys[ref.Name] = true
+	}
+	for _, key := range sortedMapKeys(shellKeys) {
+		if value, ok := os.LookupEnv(key); ok {
+			env.Set(key, value, "shell environment")
+		}
+	}
+
+	if remoteEntry != nil {
+		for _, key := range sortedMapKeys(remoteEntry.Env) {
+			env.Set(key, remoteEntry.Env[key], "@project registry entry")
+		}
+	}
+	if cfg != nil && name != "" {
+		effective, err := cfg.EffectiveProfile(name)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, key := range sortedMapKeys(effective.Environment) {
+			owner := effective.Sources[config.FieldEnvironment+"."+key]
+			env.Set(key, effective.Environment[key], fmt.Sprintf("environment of profile '%s'", owner))
+		}
+	}
+	return env, refs, nil
+}
+
+// relativePaths returns paths relative to dir where possible
+func relativePaths(dir string, paths []string) []string {
+	rel := make([]string, len(paths))
+	for i, path := range paths {
+		rel[i] = path
+		if r, err := filepath.Rel(dir, path); err == nil && !strings.HasPrefix(r, "..") {
+			rel[i] = r
+		}
+	}
+	return rel
+}
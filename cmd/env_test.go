@@ -0,0 +1,73 @@
+package commands
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunEnv(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"compose.yaml": "services:\n  api:\n    image: \"api:${TAG:-latest}\"\n    command: echo ${DOX_TEST_UNSET:?} $DEBUG\n",
+		".env":         "DEBUG=false\nDB_PASSWORD=secret\n",
+		".env.local":   "DEBUG=true\n",
+		"dox.yaml": `
+version: 1
+profiles:
+  base:
+    env_files: [.env]
+    environment:
+      REGION: eu
+  dev:
+    extends: base
+    env_files: [.env.local, "?.env.missing"]
+`,
+	} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+
+	original, _ := os.Getwd()
+	require.NoError(t, os.Chdir(dir))
+	defer os.Chdir(original)
+
+	profile = "dev"
+	defer func() { profile = "" }()
+
+	var out bytes.Buffer
+	require.NoError(t, runEnv(&out))
+	assert.Equal(t, `# profile dev (--profile flag)
+DB_PASSWORD=********  # .env
+DEBUG=true  # .env.local (overrides .env)
+REGION=eu  # environment of profile 'base'
+
+Unset variables referenced in compose files:
+  DOX_TEST_UNSET  # compose.yaml (required)
+  TAG  # compose.yaml (has a default)
+`, out.String())
+
+	t.Setenv("DEBUG", "1")
+	envReveal = true
+	defer func() { envReveal = false }()
+
+	out.Reset()
+	require.NoError(t, runEnv(&out))
+	assert.Contains(t, out.String(), "DB_PASSWORD=secret  # .env\n")
+	assert.Contains(t, out.String(), "DEBUG=1  # shell environment (overrides .env, .env.local)\n")
+}
+
+func TestRunEnvDiff(t *testing.T) {
+	chdirFixture(t, "with-env")
+
+	var out bytes.Buffer
+	require.NoError(t, runEnvDiff(&out, "dev", "prod"))
+	assert.Equal(t, `--- dev
++++ prod
+~ DATABASE_URL: postgresql://localhost:5432/dev -> postgresql://prod-db:5432/app  # .env.prod
+~ DEBUG: true -> false  # .env.prod
+`, out.String())
+}
@@ -12,8 +12,9 @@ var execCmd = &cobra.Command{
 
 Requires at least a service name. Common usage: dox c exec api bash`,
 	Args: cobra.MinimumNArgs(1),
+	ValidArgsFunction: completeServices,
 	RunE: func(cmd *cobra.Command, args []string) error {
-	 return executeCommand(func(b *Builder, a []string) ([]string, error) {
+	 return executeCommand("exec", func(b *Builder, a []string) ([]string, error) {
    return b.BuildExec(a)
 	 }, args)
 	},
@@ -0,0 +1,206 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/AkaraChen/dox/internal/config"
+	"github.com/spf13/cobra"
+)
+
+// explainCmd represents the explain command
+var explainCmd = &cobra.Command{
+	Use:   "explain",
+	Short: "Explain why each compose file was chosen",
+	Long: `Trace how dox picks the compose files and env file for a command.
+
+Shows where the profile came from (--profile flag, @project registry entry,
+default_profile in dox.yaml or auto-discovery), each extends hop, the
+profile that lists each slice and the file it maps to, duplicate slices that
+were skipped, which base file won and why, and the env file precedence.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runExplain(os.Stdout)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(explainCmd)
+	explainCmd.Flags().StringVarP(&profile, "profile", "p", "", "profile to explain from dox.yaml")
+	explainCmd.RegisterFlagCompletionFunc("profile", completeProfiles)
+}
+
+// runExplain writes the file resolution trace for the current directory
+func runExplain(w io.Writer) error {
+	dir, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	cfg, err := loadConfigIn(dir)
+	if err != nil {
+		return err
+	}
+	discovery, err := config.DiscoverFiles(dir)
+	if err != nil {
+		return err
+	}
+
+	rel := func(path string) string {
+		if r, err := filepath.Rel(dir, path); err == nil && !strings.HasPrefix(r, "..") {
+			return r
+		}
+		return path
+	}
+
+	profileToUse, source := selectedProfile(cfg)
+	if source == "default_profile" || source == "defaults.profile" {
+		source += " in " + rel(cfg.Path)
+	}
+
+	if cfg != nil {
+		fmt.Fprintf(w, "Config: %s\n", rel(cfg.Path))
+	} else {
+		fmt.Fprintln(w, "Config: none (no dox.yaml)")
+	}
+
+	if profileToUse == "" || cfg == nil {
+		if profileToUse != "" {
+			fmt.Fprintf(w, "Profile: %s from %s is ignored without a dox.yaml\n", profileToUse, source)
+		}
+		fmt.Fprintln(w, "Profile: none, using auto-discovery")
+		explainDiscovery(w, discovery, rel)
+		return nil
+	}
+
+	fmt.Fprintf(w, "Profile: %s (from %s)\n", profileToUse, source)
+	trace, err := cfg.TraceProfile(profileToUse, discovery)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range trace.Order {
+		if parents := trace.Extends[name]; len(parents) > 0 {
+			fmt.Fprintf(w, "  %s extends %s\n", name, strings.Join(parents, ", "))
+		}
+	}
+	if len(trace.Order) > 1 {
+		fmt.Fprintf(w, "  slice order: %s\n", strings.Join(trace.Order, ", "))
+	}
+
+	fmt.Fprintln(w)
+	explainBase(w, trace.BaseFile, trace.ShadowedBases, rel)
+
+	fmt.Fprintln(w)
+	if len(trace.Slices) == 0 {
+		fmt.Fprintln(w, "Slices: none")
+	} else {
+		fmt.Fprintln(w, "Slices:")
+	}
+	for _, slice := range trace.Slices {
+		switch {
+		case slice.Excluded:
+			fmt.Fprintf(w, "  !%s (from profile %s): excluded\n", slice.Name, slice.Profile)
+			continue
+		case slice.Duplicate:
+			fmt.Fprintf(w, "  %s (from profile %s): skipped, already included\n", slice.Name, slice.Profile)
+			continue
+		case slice.DroppedBy != "":
+			fmt.Fprintf(w, "  %s (from profile %s): dropped by profile %s\n", slice.Name, slice.Profile, slice.DroppedBy)
+			continue
+		}
+		if slice.Files != nil {
+			files := make([]string, len(slice.Files))
+			for i, file := range slice.Files {
+				files[i] = rel(file)
+			}
+			fmt.Fprintf(w, "  %s (from profile %s, listed under slices) -> %s\n", slice.Name, slice.Profile, strings.Join(files, ", "))
+			continue
+		}
+		fmt.Fprintf(w, "  %s (from profile %s) -> %s\n", slice.Name, slice.Profile, rel(slice.File))
+		if slice.Shadowed != "" {
+			fmt.Fprintf(w, "    %s ignored, .yaml takes precedence over .yml\n", rel(slice.Shadowed))
+		}
+	}
+
+	fmt.Fprintln(w)
+	switch {
+	case trace.EnvFile != "":
+		fmt.Fprintf(w, "Env file: %s (%s)\n", trace.EnvFile, trace.EnvFileSource)
+	case trace.EnvFileSource != "":
+		fmt.Fprintf(w, "Env file: none (%s)\n", trace.EnvFileSource)
+	default:
+		fmt.Fprintf(w, "Env file: none (profile '%s' sets no env_file or env)\n", trace.Profile)
+	}
+
+	effective, err := cfg.EffectiveProfile(profileToUse)
+	if err != nil {
+		return err
+	}
+	if len(effective.EnvFiles) > 0 {
+		fmt.Fprintln(w, "Env files (passed after the env file, later files win):")
+	}
+	for i, entry := range effective.EnvFiles {
+		path, optional := config.ParseEnvFileEntry(entry)
+		note := ""
+		if optional {
+			note = ", optional"
+			fullPath := path
+			if !filepath.IsAbs(fullPath) {
+				fullPath = filepath.Join(dir, path)
+			}
+			if _, err := os.Stat(fullPath); err != nil {
+				note += ", skipped: not found"
+			}
+		}
+		fmt.Fprintf(w, "  %s (from profile %s%s)\n", path, effective.Sources[fmt.Sprintf("env_files[%d]", i)], note)
+	}
+
+	fmt.Fprintln(w)
+	explainFiles(w, trace.Files, rel)
+	return nil
+}
+
+// explainDiscovery writes the trace of auto-discovery, used without a profile
+func explainDiscovery(w io.Writer, discovery *config.Discovery, rel func(string) string) {
+	fmt.Fprintln(w)
+	var shadowed []string
+	if discovery.BaseFile != "" {
+		shadowed = config.ShadowedBaseFiles(discovery.BaseFile)
+	}
+	explainBase(w, discovery.BaseFile, shadowed, rel)
+
+	fmt.Fprintln(w)
+	if len(discovery.Files) == 0 {
+		fmt.Fprintln(w, "No compose files found")
+		return
+	}
+	if len(discovery.Slices) > 0 {
+		fmt.Fprintln(w, "Slices: every compose.*.yaml, sorted by name")
+	}
+	explainFiles(w, discovery.Files, rel)
+}
+
+// explainBase writes which base file won and which were shadowed
+func explainBase(w io.Writer, base string, shadowed []string, rel func(string) string) {
+	if base == "" {
+		fmt.Fprintln(w, "Base file: none found")
+		return
+	}
+	fmt.Fprintf(w, "Base file: %s\n", rel(base))
+	for _, path := range shadowed {
+		fmt.Fprintf(w, "  %s ignored, %s takes precedence\n", rel(path), filepath.Base(base))
+	}
+	fmt.Fprintf(w, "  preference: %s\n", strings.Join(config.BaseFileNames, ", "))
+}
+
+// explainFiles writes the final -f order
+func explainFiles(w io.Writer, files []string, rel func(string) string) {
+	fmt.Fprintln(w, "Files (in -f order):")
+	for i, file := range files {
+		fmt.Fprintf(w, "  %d. %s\n", i+1, rel(file))
+	}
+}
@@ -0,0 +1,65 @@
+package commands
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunExplain(t *testing.T) {
+	chdirFixture(t, "with-profiles")
+
+	var out bytes.Buffer
+	require.NoError(t, runExplain(&out))
+	assert.Contains(t, out.String(), "Profile: dev (from defaults.profile in dox.yaml)")
+	assert.Contains(t, out.String(), "dev (from profile dev) -> compose.dev.yaml")
+	assert.Contains(t, out.String(), "  1. compose.yaml\n  2. compose.dev.yaml\n")
+
+	profile = "full"
+	defer func() { profile = "" }()
+	out.Reset()
+	require.NoError(t, runExplain(&out))
+	assert.Contains(t, out.String(), "Profile: full (from --profile flag)")
+	assert.Contains(t, out.String(), "  3. compose.prod.yaml\n")
+
+	profile = "missing"
+	assert.Error(t, runExplain(&out))
+}
+
+func TestRunExplain_AutoDiscovery(t *testing.T) {
+	chdirFixture(t, "multi-slice")
+
+	var out bytes.Buffer
+	require.NoError(t, runExplain(&out))
+	assert.Contains(t, out.String(), "Profile: none, using auto-discovery")
+	assert.Contains(t, out.String(), "  5. compose.redis.yaml\n")
+}
+
+func TestRunExplain_ListedSlices(t *testing.T) {
+	dir := chdirTemp(t, "compose.yaml", "compose.dev.yaml")
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "ops"), 0755))
+	for _, name := range []string{"ops/grafana.yaml", "ops/prometheus.yaml"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte("services: {}\n"), 0644))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "dox.yaml"), []byte(`
+version: 2
+default_profile: dev
+slices:
+  base: [compose.yaml]
+  monitoring: [ops/]
+profiles:
+  dev:
+    slices: [base, dev, monitoring]
+`), 0644))
+
+	var out bytes.Buffer
+	require.NoError(t, runExplain(&out))
+	assert.Contains(t, out.String(), "Profile: dev (from default_profile in dox.yaml)")
+	assert.Contains(t, out.String(), "  base (from profile dev, listed under slices) -> compose.yaml\n")
+	assert.Contains(t, out.String(), "  monitoring (from profile dev, listed under slices) -> ops/grafana.yaml, ops/prometheus.yaml\n")
+	assert.Contains(t, out.String(), "  1. compose.yaml\n  2. compose.dev.yaml\n  3. ops/grafana.yaml\n  4. ops/prometheus.yaml\n")
+}
@@ -0,0 +1,81 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	composepkg "github.com/AkaraChen/dox/internal/compose"
+	"github.com/AkaraChen/dox/internal/model"
+	"github.com/spf13/cobra"
+)
+
+var (
+	graphFormat      string
+	graphDiffProfile string
+)
+
+// graphCmd represents the graph command
+var graphCmd = &cobra.Command{
+	Use:   "graph",
+	Short: "Show the service dependency graph",
+	Long: `Show the topology of the merged compose files for a profile.
+
+Prints services with their published ports, depends_on edges with their
+conditions, the networks services join and the named volumes they mount.
+Use --format dot for Graphviz, --format mermaid for Markdown docs, and
+--diff-profile to highlight what another profile adds, removes or changes.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runGraph()
+	},
+}
+
+func init() {
+	composeGroupCmd.AddCommand(graphCmd)
+	graphCmd.Flags().StringVar(&graphFormat, "format", model.GraphText, "output format: text, dot or mermaid")
+	graphCmd.Flags().StringVar(&graphDiffProfile, "diff-profile", "", "profile to compare against")
+	graphCmd.RegisterFlagCompletionFunc("diff-profile", completeProfiles)
+}
+
+// runGraph prints the topology of the current profile
+func runGraph() error {
+	builder, err := getFilesBuilder()
+	if err != nil {
+		return err
+	}
+
+	graph, err := profileGraph(builder)
+	if err != nil {
+		return err
+	}
+
+	if graphDiffProfile != "" {
+		cfg, err := getConfig()
+		if err != nil {
+			return err
+		}
+		if cfg == nil {
+			return fmt.Errorf("--diff-profile requires a dox.yaml")
+		}
+		other, err := profileGraph(composepkg.NewBuilder(builder.Dir(), cfg, graphDiffProfile))
+		if err != nil {
+			return fmt.Errorf("profile '%s': %w", graphDiffProfile, err)
+		}
+		graph = graph.Diff(other)
+	}
+
+	return graph.Write(os.Stdout, graphFormat)
+}
+
+// profileGraph loads the compose model for a builder's profile as a graph
+func profileGraph(builder *Builder) (*model.Graph, error) {
+	files, err := builder.Files()
+	if err != nil {
+		return nil, err
+	}
+	project, err := model.Load(files...)
+	if err != nil {
+		return nil, err
+	}
+	return model.NewGraph(project), nil
+}
@@ -0,0 +1,33 @@
+package commands
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunGraph_DiffProfile(t *testing.T) {
+	chdirFixture(t, "with-profiles")
+	profile = "dev"
+	graphDiffProfile = "prod"
+	defer func() {
+		profile = ""
+		graphDiffProfile = ""
+	}()
+
+	original := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	err := runGraph()
+
+	w.Close()
+	os.Stdout = original
+	buf := make([]byte, 1024)
+	n, _ := r.Read(buf)
+
+	require.NoError(t, err)
+	assert.Equal(t, "app\n", string(buf[:n]))
+}
@@ -0,0 +1,174 @@
+package commands
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/AkaraChen/dox/internal/config"
+	"github.com/AkaraChen/dox/internal/scaffold"
+	"github.com/spf13/cobra"
+)
+
+var (
+	initYes   bool
+	initForce bool
+)
+
+// initCmd represents the init command
+var initCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Create a dox.yaml from the compose and env files found",
+	Long: `Create a commented dox.yaml for the current directory.
+
+dox init discovers compose.yaml and its compose.SLICE.yaml slices and proposes
+a profile for each environment it recognizes in slice names and .env.NAME
+files: dev, test, staging and prod. Every slice is listed under slices, and
+each env file becomes the env_file of the matching profile. Slices and env
+files no profile uses are noted in comments.
+
+dox init asks for the default profile, whether to add starter aliases and
+whether to write the file. Use --yes to accept the proposal without prompting.
+An existing dox.yaml is only replaced with --force. The local override files
+dox.override.yaml and dox.local.yaml are added to .gitignore. With --dry-run
+the file is printed instead of written.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runInit(os.Stdin, os.Stdout, initYes)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(initCmd)
+	initCmd.Flags().BoolVarP(&initYes, "yes", "y", false, "write the proposed dox.yaml without prompting")
+	initCmd.Flags().BoolVarP(&initForce, "force", "f", false, "replace an existing dox.yaml")
+}
+
+// runInit proposes a dox.yaml and writes it, asking on in unless yes is set
+func runInit(in io.Reader, out io.Writer, yes bool) error {
+	dir, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(dir, "dox.yaml")
+	if _, err := os.Stat(path); err == nil && !initForce {
+		return fmt.Errorf("dox.yaml already exists; use --force to replace it")
+	}
+
+	proposal, err := scaffold.Propose(dir)
+	if err != nil {
+		return err
+	}
+	describeProposal(out, proposal)
+
+	opts := scaffold.Options{DefaultProfile: proposal.DefaultProfile(), Aliases: true}
+	if !yes {
+		reader := bufio.NewReader(in)
+		if len(proposal.Profiles) > 1 {
+			answer, err := ask(reader, out, "Default profile", opts.DefaultProfile)
+			if err != nil {
+				return err
+			}
+			if !proposalHasProfile(proposal, answer) {
+				return fmt.Errorf("profile '%s' is not one of the proposed profiles", answer)
+			}
+			opts.DefaultProfile = answer
+		}
+		if opts.Aliases, err = confirm(reader, out, "Add starter aliases?"); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(out, "\n%s\n", proposal.Render(opts))
+		write, err := confirm(reader, out, "Write dox.yaml?")
+		if err != nil {
+			return err
+		}
+		if !write {
+			fmt.Fprintln(out, "Nothing written")
+			return nil
+		}
+	}
+
+	if IsDryRun() {
+		fmt.Fprint(out, proposal.Render(opts))
+		return nil
+	}
+	if err := os.WriteFile(path, []byte(proposal.Render(opts)), 0644); err != nil {
+		return err
+	}
+	fmt.Fprintln(out, "Wrote dox.yaml")
+
+	added, err := config.IgnoreLocalFiles(dir)
+	if err != nil {
+		return err
+	}
+	if len(added) > 0 {
+		fmt.Fprintf(out, "Added %s to .gitignore\n", strings.Join(added, ", "))
+	}
+	return nil
+}
+
+// describeProposal lists the files the proposal is based on
+func describeProposal(w io.Writer, p *scaffold.Proposal) {
+	if p.BaseFile == "" {
+		fmt.Fprintln(w, "No base compose file found")
+	} else {
+		fmt.Fprintf(w, "Found %s\n", p.BaseFile)
+	}
+	for _, profile := range p.Profiles {
+		var from []string
+		for _, slice := range profile.Slices {
+			from = append(from, p.SliceFiles[slice])
+		}
+		if profile.Env != "" {
+			from = append(from, ".env."+profile.Env)
+		}
+		fmt.Fprintf(w, "Proposing profile %s from %s\n", profile.Name, strings.Join(from, ", "))
+	}
+	if len(p.OtherSlices) > 0 {
+		fmt.Fprintf(w, "Slices not assigned to a profile: %s\n", strings.Join(p.OtherSlices, ", "))
+	}
+}
+
+func proposalHasProfile(p *scaffold.Proposal, name string) bool {
+	for _, profile := range p.Profiles {
+		if profile.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ask prompts for a value, returning def for an empty answer
+func ask(r *bufio.Reader, w io.Writer, question, def string) (string, error) {
+	if def != "" {
+		question = fmt.Sprintf("%s [%s]", question, def)
+	}
+	fmt.Fprintf(w, "%s: ", question)
+	line, err := r.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", fmt.Errorf("no answer to %q; use --yes to skip prompts", question)
+	}
+	if answer := strings.TrimSpace(line); answer != "" {
+		return answer, nil
+	}
+	return def, nil
+}
+
+// confirm prompts for a yes or no answer, defaulting to yes
+func confirm(r *bufio.Reader, w io.Writer, question string) (bool, error) {
+	answer, err := ask(r, w, question+" [Y/n]", "")
+	if err != nil {
+		return false, err
+	}
+	switch strings.ToLower(answer) {
+	case "", "y", "yes":
+		return true, nil
+	case "n", "no":
+		return false, nil
+	}
+	return false, fmt.Errorf("answer %q to %q is not yes or no", answer, question)
+}
@@ -0,0 +1,82 @@
+package commands
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/AkaraChen/dox/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func chdirTemp(t *testing.T, files ...string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for _, name := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte("services: {}\n"), 0644))
+	}
+	original, _ := os.Getwd()
+	t.Cleanup(func() { os.Chdir(original) })
+	require.NoError(t, os.Chdir(dir))
+	return dir
+}
+
+func TestRunInit_Yes(t *testing.T) {
+	dir := chdirTemp(t, "compose.yaml", "compose.dev.yaml", "compose.prod.yaml", ".env.dev")
+
+	var out bytes.Buffer
+	require.NoError(t, runInit(strings.NewReader(""), &out, true))
+	assert.Contains(t, out.String(), "Proposing profile dev from compose.dev.yaml, .env.dev\n")
+	assert.Contains(t, out.String(), "Added dox.override.yaml, dox.local.yaml to .gitignore\n")
+
+	cfg, err := config.LoadConfig(filepath.Join(dir, "dox.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, "dev", cfg.GetDefaultProfile())
+	assert.Equal(t, ".env.dev", cfg.Profiles["dev"].EnvFile)
+
+	err = runInit(strings.NewReader(""), &out, true)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "use --force")
+
+	initForce = true
+	defer func() { initForce = false }()
+	assert.NoError(t, runInit(strings.NewReader(""), &out, true))
+}
+
+func TestRunInit_YmlSlices(t *testing.T) {
+	chdirTemp(t, "docker-compose.yml", "compose.dev.yml")
+
+	var out bytes.Buffer
+	require.NoError(t, runInit(strings.NewReader(""), &out, true))
+	assert.Contains(t, out.String(), "Found docker-compose.yml\n")
+	assert.Contains(t, out.String(), "Proposing profile dev from compose.dev.yml\n")
+}
+
+func TestRunInit_Prompts(t *testing.T) {
+	dir := chdirTemp(t, "compose.yaml", "compose.dev.yaml", "compose.prod.yaml")
+
+	var out bytes.Buffer
+	require.NoError(t, runInit(strings.NewReader("prod\nn\n\n"), &out, false))
+	assert.Contains(t, out.String(), "Default profile [dev]: ")
+
+	cfg, err := config.LoadConfig(filepath.Join(dir, "dox.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, "prod", cfg.GetDefaultProfile())
+	assert.Empty(t, cfg.Aliases)
+}
+
+func TestRunInit_Declined(t *testing.T) {
+	dir := chdirTemp(t, "compose.yaml")
+
+	var out bytes.Buffer
+	require.NoError(t, runInit(strings.NewReader("y\nno\n"), &out, false))
+	assert.Contains(t, out.String(), "Nothing written")
+	assert.NoFileExists(t, filepath.Join(dir, "dox.yaml"))
+
+	err := runInit(strings.NewReader(""), &out, false)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "use --yes")
+}
@@ -0,0 +1,69 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/AkaraChen/dox/internal/lint"
+	"github.com/spf13/cobra"
+)
+
+var lintFormat string
+
+// lintCmd represents the lint command
+var lintCmd = &cobra.Command{
+	Use:   "lint",
+	Short: "Check compose slices for conflicts and override mistakes",
+	Long: `Check the compose files and profiles in the current directory for
+common mistakes, without contacting the docker daemon.
+
+Rules:
+  conflicting-image    a service sets different images in files a profile combines
+  port-collision       two services publish the same host port in a profile
+  missing-dependency   a depends_on target is not defined in a profile
+  unreferenced-slice   a slice file is not used by any profile
+  yml-yaml-twins       both compose.X.yml and compose.X.yaml exist
+  missing-env-file     a profile or service env file does not exist
+  unresolved-profile   a profile cannot be resolved to compose files
+
+Change a rule's severity (error, warning or off) under lint.rules in
+dox.yaml. Use --format sarif to upload results to code scanning tools.
+Exits non-zero when any error is found.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runLint()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(lintCmd)
+	lintCmd.Flags().StringVar(&lintFormat, "format", lint.FormatText, "output format: text, json or sarif")
+	lintCmd.RegisterFlagCompletionFunc("format", cobra.FixedCompletions(
+		[]string{lint.FormatText, lint.FormatJSON, lint.FormatSARIF}, cobra.ShellCompDirectiveNoFileComp))
+}
+
+// runLint lints the current directory and prints the report
+func runLint() error {
+	dir, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	cfg, err := getConfig()
+	if err != nil {
+		return err
+	}
+
+	report, err := lint.Run(dir, cfg)
+	if err != nil {
+		return err
+	}
+	if err := report.Write(os.Stdout, lintFormat); err != nil {
+		return err
+	}
+
+	if errors, _ := report.Counts(); errors > 0 {
+		return fmt.Errorf("lint found %d errors", errors)
+	}
+	return nil
+}
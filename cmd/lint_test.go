@@ -0,0 +1,38 @@
+package commands
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/AkaraChen/dox/internal/lint"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunLint_JSON(t *testing.T) {
+	chdirFixture(t, "with-env")
+	lintFormat = lint.FormatJSON
+	defer func() { lintFormat = lint.FormatText }()
+
+	original := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	err := runLint()
+
+	w.Close()
+	os.Stdout = original
+	require.NoError(t, err)
+
+	var report lint.Report
+	require.NoError(t, json.NewDecoder(r).Decode(&report))
+	assert.Empty(t, report.Findings)
+}
+
+func TestLintCmd_Registered(t *testing.T) {
+	cmd, _, err := rootCmd.Find([]string{"lint"})
+	require.NoError(t, err)
+	assert.Equal(t, "lint", cmd.Name())
+	assert.NotNil(t, cmd.Flags().Lookup("format"))
+}
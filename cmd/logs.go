@@ -12,8 +12,9 @@ var logsCmd = &cobra.Command{
 
 Can show logs for all services or specific services. Supports -f (follow) and --tail flags.`,
 	Args: cobra.ArbitraryArgs,
+	ValidArgsFunction: completeServices,
 	RunE: func(cmd *cobra.Command, args []string) error {
-	 return executeCommand(func(b *Builder, a []string) ([]string, error) {
+	 return executeCommand("logs", func(b *Builder, a []string) ([]string, error) {
    return b.BuildLogs(a)
 	 }, args)
 	},
@@ -11,7 +11,7 @@ var psCmd = &cobra.Command{
 	Long: `List running containers for the current compose project.`,
 	Args: cobra.ArbitraryArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
-	 return executeCommand(func(b *Builder, a []string) ([]string, error) {
+	 return executeCommand("ps", func(b *Builder, a []string) ([]string, error) {
    return b.BuildPs(a)
 	 }, args)
 	},
@@ -0,0 +1,119 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	composepkg "github.com/AkaraChen/dox/internal/compose"
+	"github.com/AkaraChen/dox/internal/project"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// remoteEntry holds the registry entry of the project selected with @project.
+// It supplies the default profile, extra env vars and docker context.
+var remoteEntry *project.ProjectEntry
+
+// executeRemote runs a dox command inside one or more registered projects.
+// ref is the @project reference and args the remaining command line.
+func executeRemote(ref string, args []string) error {
+	globalCfg, err := project.LoadGlobalConfigOrDefault(project.GetGlobalConfigPath())
+	if err != nil {
+		return err
+	}
+
+	_, projectName, _ := project.ParseAtProjectReference(ref)
+	if projectName == project.AllProjectsName && !globalCfg.HasProject(projectName) {
+		targets := globalCfg.ResolveAllProjects("")
+		if len(targets) == 0 {
+			return fmt.Errorf("no projects included in @all")
+		}
+		// Every project runs even if one fails; failures are reported as
+		// they happen and summed up at the end. Headers go to stderr so
+		// stdout stays machine-readable.
+		result := &allProjectsError{total: len(targets)}
+		for _, target := range targets {
+			fmt.Fprintf(os.Stderr, "==> %s (%s)\n", target.ProjectName, target.ProjectPath)
+			if err := runInProject(target, args); err != nil {
+				reportError(fmt.Errorf("project '%s': %w", target.ProjectName, err))
+				result.add(target.ProjectName, err)
+			}
+		}
+		if len(result.failed) > 0 {
+			return result
+		}
+		return nil
+	}
+
+	target, err := globalCfg.ResolveRemoteProject(ref)
+	if err != nil {
+		return err
+	}
+	return runInProject(target, args)
+}
+
+// runInProject executes the root command with the project directory as cwd
+func runInProject(target *project.RemoteProject, args []string) error {
+	originalDir, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	defer os.Chdir(originalDir)
+
+	if err := os.Chdir(target.ProjectPath); err != nil {
+		return fmt.Errorf("failed to enter project directory: %w", err)
+	}
+
+	remoteEntry = &target.Entry
+	defer func() { remoteEntry = nil }()
+
+	// Flags set for a previous project must not carry over
+	resetFlags(rootCmd)
+	rootCmd.SetArgs(args)
+	return rootCmd.Execute()
+}
+
+// resetFlags returns every flag of cmd and its subcommands to its default
+func resetFlags(cmd *cobra.Command) {
+	reset := func(f *pflag.Flag) {
+		if !f.Changed {
+			return
+		}
+		if list, ok := f.Value.(pflag.SliceValue); ok {
+			list.Replace(nil)
+		} else {
+			f.Value.Set(f.DefValue)
+		}
+		f.Changed = false
+	}
+	cmd.Flags().VisitAll(reset)
+	cmd.PersistentFlags().VisitAll(reset)
+	for _, sub := range cmd.Commands() {
+		resetFlags(sub)
+	}
+}
+
+// allProjectsError reports the projects that failed in an @all run. dox
+// exits with the exit code of the first failure.
+type allProjectsError struct {
+	total  int
+	failed []string
+	first  error
+}
+
+func (e *allProjectsError) add(name string, err error) {
+	if e.first == nil {
+		e.first = err
+	}
+	e.failed = append(e.failed, name)
+}
+
+func (e *allProjectsError) Error() string {
+	return fmt.Sprintf("%d of %d projects failed: %s", len(e.failed), e.total, strings.Join(e.failed, ", "))
+}
+
+// ExitCode returns the exit code of the first failed project
+func (e *allProjectsError) ExitCode() int {
+	return composepkg.ExitCode(e.first)
+}
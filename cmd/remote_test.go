@@ -0,0 +1,133 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	composepkg "github.com/AkaraChen/dox/internal/compose"
+	"github.com/AkaraChen/dox/internal/project"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetComposeBuilder_RemoteEntryProfile(t *testing.T) {
+	fixtureDir := filepath.Join("..", "test", "fixtures", "with-profiles")
+	originalDir, _ := os.Getwd()
+	defer os.Chdir(originalDir)
+
+	err := os.Chdir(fixtureDir)
+	require.NoError(t, err)
+
+	remoteEntry = &project.ProjectEntry{Profile: "prod"}
+	defer func() { remoteEntry = nil }()
+
+	builder, err := getComposeBuilder()
+	require.NoError(t, err)
+
+	cmd, err := builder.BuildUp(nil)
+	require.NoError(t, err)
+	line := strings.Join(cmd, " ")
+	assert.Contains(t, line, "compose.prod.yaml")
+	assert.NotContains(t, line, "compose.dev.yaml")
+}
+
+func TestGetComposeBuilder_ProfileFlagOverridesRemoteEntry(t *testing.T) {
+	fixtureDir := filepath.Join("..", "test", "fixtures", "with-profiles")
+	originalDir, _ := os.Getwd()
+	defer os.Chdir(originalDir)
+
+	err := os.Chdir(fixtureDir)
+	require.NoError(t, err)
+
+	remoteEntry = &project.ProjectEntry{Profile: "prod"}
+	profile = "dev"
+	defer func() {
+		remoteEntry = nil
+		profile = ""
+	}()
+
+	builder, err := getComposeBuilder()
+	require.NoError(t, err)
+
+	cmd, err := builder.BuildUp(nil)
+	require.NoError(t, err)
+	line := strings.Join(cmd, " ")
+	assert.Contains(t, line, "compose.dev.yaml")
+	assert.NotContains(t, line, "compose.prod.yaml")
+}
+
+func TestGetComposeExecutor_RemoteEntryEnv(t *testing.T) {
+	remoteEntry = &project.ProjectEntry{
+		Env:     map[string]string{"TAG": "stable"},
+		Context: "shared-box",
+	}
+	defer func() { remoteEntry = nil }()
+
+	executor := getComposeExecutor(nil)
+	assert.Contains(t, executor.Env, "TAG=stable")
+	assert.Contains(t, executor.Env, "DOCKER_CONTEXT=shared-box")
+}
+
+func TestExecuteRemote_UnknownProject(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	err := executeRemote("@missing", []string{"c", "ps"})
+	assert.Error(t, err)
+}
+
+func TestExecuteRemote_RunsInProjectDirectory(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	fixtureDir, err := filepath.Abs(filepath.Join("..", "test", "fixtures", "with-profiles"))
+	require.NoError(t, err)
+
+	globalPath := project.GetGlobalConfigPath()
+	require.NoError(t, os.MkdirAll(filepath.Dir(globalPath), 0755))
+	content := []byte("projects:\n  app:\n    path: " + fixtureDir + "\n    profile: prod\n")
+	require.NoError(t, os.WriteFile(globalPath, content, 0644))
+
+	originalDir, _ := os.Getwd()
+	defer func() { dryRun = false }()
+
+	err = executeRemote("@app", []string{"--dry-run", "c", "ps"})
+	assert.NoError(t, err)
+
+	cwd, _ := os.Getwd()
+	assert.Equal(t, originalDir, cwd)
+	assert.Nil(t, remoteEntry)
+}
+
+func TestExecuteRemote_AllContinuesAfterFailure(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	fixtureDir, err := filepath.Abs(filepath.Join("..", "test", "fixtures", "with-profiles"))
+	require.NoError(t, err)
+	broken := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(broken, "dox.yaml"), []byte("version: 9\n"), 0644))
+
+	globalPath := project.GetGlobalConfigPath()
+	require.NoError(t, os.MkdirAll(filepath.Dir(globalPath), 0755))
+	content := []byte("projects:\n  a-broken:\n    path: " + broken + "\n  b-app:\n    path: " + fixtureDir + "\n  c-broken:\n    path: " + broken + "\n")
+	require.NoError(t, os.WriteFile(globalPath, content, 0644))
+	defer func() { dryRun = false }()
+
+	err = executeRemote("@all", []string{"--dry-run", "c", "ps"})
+	var all *allProjectsError
+	require.ErrorAs(t, err, &all)
+	assert.Equal(t, "2 of 3 projects failed: a-broken, c-broken", err.Error())
+	assert.Equal(t, composepkg.ExitConfigError, composepkg.ExitCode(err))
+}
+
+func TestResetFlags(t *testing.T) {
+	require.NoError(t, rootCmd.PersistentFlags().Set("verbose", "true"))
+	require.NoError(t, configSchemaCmd.Flags().Set("global", "true"))
+
+	resetFlags(rootCmd)
+	assert.False(t, verbose)
+	assert.False(t, configSchemaGlobal)
+	assert.False(t, rootCmd.PersistentFlags().Lookup("verbose").Changed)
+}
@@ -12,8 +12,9 @@ var restartCmd = &cobra.Command{
 
 Requires at least one service name as argument.`,
 	Args: cobra.MinimumNArgs(1),
+	ValidArgsFunction: completeServices,
 	RunE: func(cmd *cobra.Command, args []string) error {
-	 return executeCommand(func(b *Builder, a []string) ([]string, error) {
+	 return executeCommand("restart", func(b *Builder, a []string) ([]string, error) {
    return b.BuildRestart(a)
 	 }, args)
 	},
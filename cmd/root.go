@@ -1,16 +1,29 @@
 package commands
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
+	composepkg "github.com/AkaraChen/dox/internal/compose"
+	"github.com/AkaraChen/dox/internal/config"
+	"github.com/AkaraChen/dox/internal/project"
 	"github.com/spf13/cobra"
 )
 
 var (
 	version = "dev"
-	verbose bool
-	dryRun  bool
+	verbose      bool
+	dryRun       bool
+	outputFormat string
+	gracePeriod  time.Duration
+
+	// commandLine is the invocation recorded in history; empty disables recording
+	commandLine string
 )
 
 // rootCmd represents the base command when called without any subcommands
@@ -22,21 +35,75 @@ multiple compose files (slices) and environment-specific configurations.
 
 It auto-discovers compose.yaml and slice files (compose.*.yaml), supports
 profile-based configuration, and provides shorthand commands for common
-operations.`,
+operations.
+
+Prefix a command with @project to run it in a project registered in
+~/.config/dox/config.yaml, or with @all to run it in every registered project.`,
 	Version: version,
+	ValidArgsFunction: completeProjects,
+	// Errors are reported by Execute; usage is only shown for argument
+	// errors, which cobra detects before PersistentPreRun.
+	SilenceErrors: true,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		cmd.SilenceUsage = true
+		// Completions must print nothing but candidates
+		configWarned = strings.HasPrefix(cmd.Name(), "__")
+	},
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
+// The process exits with the main command's own exit code, or one of the dox
+// exit codes documented in the compose package.
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
-	 fmt.Fprintln(os.Stderr, err)
-	 os.Exit(1)
+	commandLine = strings.Join(append([]string{"dox"}, os.Args[1:]...), " ")
+
+	var err error
+	args := os.Args[1:]
+	switch {
+	case len(args) > 0 && project.IsAtProjectReference(args[0]):
+	 err = executeRemote(args[0], args[1:])
+	case len(args) > 2 && args[0] == cobra.ShellCompRequestCmd && project.IsAtProjectReference(args[1]):
+	 err = completeRemote(args[1], args[2:])
+	default:
+	 err = rootCmd.Execute()
+	}
+
+	if err != nil {
+	 reportError(err)
+	 os.Exit(composepkg.ExitCode(err))
 	}
 }
 
 func init() {
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "verbose output")
 	rootCmd.PersistentFlags().BoolVar(&dryRun, "dry-run", false, "show commands without executing")
+	rootCmd.PersistentFlags().StringVar(&outputFormat, "output", "text", "dry-run output format: text, json or sh")
+	rootCmd.PersistentFlags().DurationVar(&gracePeriod, "grace-period", composepkg.DefaultGracePeriod, "time an interrupted command gets to exit before it is killed")
+}
+
+// reportError prints err unless it is the non-zero exit of the main command,
+// which has already reported its own failure
+func reportError(err error) {
+	var exitErr *composepkg.ExitError
+	var hookErr *composepkg.HookError
+	if errors.As(err, &exitErr) && !errors.As(err, &hookErr) && !IsVerbose() {
+		return
+	}
+	fmt.Fprintln(os.Stderr, "Error:", err)
+}
+
+// warnConfig reports unset variables and legacy keys in dox.yaml
+func warnConfig(w io.Writer, cfg *config.Config) {
+	for _, warning := range cfg.Warnings {
+		fmt.Fprintf(w, "Warning: %s\n", warning)
+	}
+	deprecations := cfg.Deprecations()
+	for _, message := range deprecations {
+		fmt.Fprintf(w, "Warning: %s: %s\n", filepath.Base(cfg.Path), message)
+	}
+	if len(deprecations) > 0 {
+		fmt.Fprintln(w, "Run 'dox config migrate' to update dox.yaml")
+	}
 }
 
 // GetRoot returns the root command
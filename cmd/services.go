@@ -0,0 +1,70 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/AkaraChen/dox/internal/model"
+)
+
+// serviceValueFlags lists, per command that takes service arguments, the
+// compose flags whose value is a separate argument
+var serviceValueFlags = map[string][]string{
+	"logs":    {"-n", "--tail", "--since", "--until", "--index"},
+	"restart": {"-t", "--timeout"},
+	"build":   {"--build-arg", "--builder", "-m", "--memory", "--progress", "--ssh"},
+	"exec":    {"-e", "--env", "-u", "--user", "-w", "--workdir", "--index"},
+}
+
+// validateServices checks the service arguments of a command against the
+// services defined in the resolved compose files and expands glob patterns.
+// exec takes a single service; everything after it is the command to run.
+func validateServices(builder *Builder, name string, args []string) ([]string, error) {
+	valueFlags, ok := serviceValueFlags[name]
+	if !ok || noValidate {
+		return args, nil
+	}
+
+	// Leave missing or unparsable files for compose to report
+	files, err := builder.Files()
+	if err != nil {
+		return args, nil
+	}
+	stack, err := model.Load(files...)
+	if err != nil {
+		if IsVerbose() {
+			fmt.Fprintf(os.Stderr, "Skipping service validation: %v\n", err)
+		}
+		return args, nil
+	}
+
+	result := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if strings.HasPrefix(arg, "-") {
+			result = append(result, arg)
+			if slices.Contains(valueFlags, arg) && i+1 < len(args) {
+				i++
+				result = append(result, args[i])
+			}
+			continue
+		}
+
+		services, err := stack.ExpandService(arg)
+		if err != nil {
+			return nil, err
+		}
+
+		if name == "exec" {
+			if len(services) > 1 {
+				return nil, fmt.Errorf("'%s' matches %d services (%s), exec needs exactly one", arg, len(services), strings.Join(services, ", "))
+			}
+			result = append(result, services[0])
+			return append(result, args[i+1:]...), nil
+		}
+		result = append(result, services...)
+	}
+	return result, nil
+}
@@ -0,0 +1,81 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/AkaraChen/dox/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func chdirFixture(t *testing.T, name string) {
+	originalDir, _ := os.Getwd()
+	t.Cleanup(func() { os.Chdir(originalDir) })
+	require.NoError(t, os.Chdir(filepath.Join("..", "test", "fixtures", name)))
+}
+
+func TestValidateServices(t *testing.T) {
+	chdirFixture(t, "services")
+	builder, err := getComposeBuilder()
+	require.NoError(t, err)
+
+	args, err := validateServices(builder, "restart", []string{"-t", "5", "worker-*", "db"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"-t", "5", "worker-email", "worker-reports", "db"}, args)
+
+	args, err = validateServices(builder, "exec", []string{"-u", "root", "api-*", "sh", "-c", "worker-*"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"-u", "root", "api-gateway", "sh", "-c", "worker-*"}, args)
+
+	_, err = validateServices(builder, "logs", []string{"--tail", "10", "apii"})
+	var unknown *model.UnknownServiceError
+	require.ErrorAs(t, err, &unknown)
+	assert.Contains(t, err.Error(), "did you mean 'api-gateway'?")
+
+	_, err = validateServices(builder, "exec", []string{"worker-*", "sh"})
+	assert.ErrorContains(t, err, "exec needs exactly one")
+
+	_, err = validateServices(builder, "build", []string{"cache-*"})
+	assert.ErrorContains(t, err, "no service matches 'cache-*'")
+}
+
+func TestValidateServices_Skipped(t *testing.T) {
+	chdirFixture(t, "services")
+	builder, err := getComposeBuilder()
+	require.NoError(t, err)
+
+	// Commands without service arguments are not validated
+	args, err := validateServices(builder, "up", []string{"nope"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"nope"}, args)
+
+	noValidate = true
+	defer func() { noValidate = false }()
+	args, err = validateServices(builder, "restart", []string{"apii"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"apii"}, args)
+}
+
+func TestExecuteCommand_InvalidServiceSkipsHooks(t *testing.T) {
+	chdirFixture(t, "with-hooks")
+	dryRun = true
+	defer func() { dryRun = false }()
+
+	original := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	err := executeCommand("restart", func(b *Builder, a []string) ([]string, error) {
+		return b.BuildRestart(a)
+	}, []string{"no-such-service"})
+
+	w.Close()
+	os.Stdout = original
+	buf := make([]byte, 1024)
+	n, _ := r.Read(buf)
+
+	assert.ErrorContains(t, err, "no such service 'no-such-service'")
+	assert.Zero(t, n)
+}
@@ -11,7 +11,7 @@ var sCmd = &cobra.Command{
 	Long:  `Shorthand for 'dox c status'. Shows enhanced status of services.`,
 	Args: cobra.ArbitraryArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
-	 return executeCommand(func(b *Builder, a []string) ([]string, error) {
+	 return executeCommand("status", func(b *Builder, a []string) ([]string, error) {
    return b.BuildStatus(a)
 	 }, args)
 	},
@@ -14,7 +14,7 @@ This is an enhanced version of 'ps' with formatted output showing
 service names, states, and port mappings in a table format.`,
 	Args: cobra.ArbitraryArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
-	 return executeCommand(func(b *Builder, a []string) ([]string, error) {
+	 return executeCommand("status", func(b *Builder, a []string) ([]string, error) {
    return b.BuildStatus(a)
 	 }, args)
 	},
@@ -14,7 +14,7 @@ Auto-discovers compose.yaml and slice files (compose.*.yaml) in the current dire
 Use -p to select a profile from dox.yaml, or -d for detached mode.`,
 	Args: cobra.ArbitraryArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
-	 return executeCommand(func(b *Builder, a []string) ([]string, error) {
+	 return executeCommand("up", func(b *Builder, a []string) ([]string, error) {
    return b.BuildUp(a)
 	 }, args)
 	},
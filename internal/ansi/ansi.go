@@ -0,0 +1,37 @@
+package ansi
+
+import (
+	"io"
+	"os"
+)
+
+// ANSI escape codes for colored text output
+const (
+	Red    = "\033[31m"
+	Green  = "\033[32m"
+	Yellow = "\033[33m"
+	Bold   = "\033[1m"
+	Reset  = "\033[0m"
+)
+
+// Enabled reports whether colors should be written to w: w is a terminal
+// and NO_COLOR is unset
+func Enabled(w io.Writer) bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// Paint wraps s in code and Reset when enabled is set
+func Paint(enabled bool, code, s string) string {
+	if !enabled {
+		return s
+	}
+	return code + s + Reset
+}
@@ -0,0 +1,28 @@
+package ansi
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPaint(t *testing.T) {
+	assert.Equal(t, "\033[31mgone\033[0m", Paint(true, Red, "gone"))
+	assert.Equal(t, "gone", Paint(false, Red, "gone"))
+}
+
+func TestEnabled(t *testing.T) {
+	assert.False(t, Enabled(&bytes.Buffer{}), "not a file")
+
+	f, err := os.Create(filepath.Join(t.TempDir(), "out"))
+	require.NoError(t, err)
+	defer f.Close()
+	assert.False(t, Enabled(f), "not a terminal")
+
+	t.Setenv("NO_COLOR", "1")
+	assert.False(t, Enabled(os.Stdout))
+}
@@ -2,25 +2,37 @@ package compose
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/AkaraChen/dox/internal/config"
 )
 
+// Commands are the compose subcommands dox recognizes in aliases and hook names
+var Commands = []string{
+	"up", "down", "ps", "logs", "restart", "exec", "build",
+	"pull", "push", "start", "stop", "rm", "kill", "run",
+	"pause", "unpause", "top", "events", "port", "config",
+	"create", "version",
+}
+
 // Builder builds docker compose commands
 type Builder struct {
 	dir        string
 	config     *config.Config
 	profile    string
 	discovery  *config.Discovery
+	runtime    Runtime
 }
 
-// NewBuilder creates a new command builder
+// NewBuilder creates a new command builder using the docker compose runtime
 func NewBuilder(dir string, cfg *config.Config, profile string) *Builder {
 	b := &Builder{
 	 dir:     dir,
 	 config:  cfg,
 	 profile: profile,
+	 runtime: DefaultRuntime(),
 	}
 
 	// Auto-discover compose files
@@ -31,6 +43,65 @@ func NewBuilder(dir string, cfg *config.Config, profile string) *Builder {
 	return b
 }
 
+// SetRuntime sets the container runtime backend used to invoke compose
+func (b *Builder) SetRuntime(rt Runtime) {
+	b.runtime = rt
+}
+
+// Runtime returns the container runtime backend
+func (b *Builder) Runtime() Runtime {
+	return b.runtime
+}
+
+// Dir returns the project directory
+func (b *Builder) Dir() string {
+	return b.dir
+}
+
+// Files returns the compose files selected for the builder's profile
+func (b *Builder) Files() ([]string, error) {
+	return b.resolveFiles()
+}
+
+// EnvFile returns the env file selected for the builder's profile, if any
+func (b *Builder) EnvFile() string {
+	return b.resolveEnvFile()
+}
+
+// EnvFiles returns the env files passed to compose, in order: the profile's
+// env_file, then its env_files entries. Optional entries that do not exist
+// are left out and returned as skipped.
+func (b *Builder) EnvFiles() (files, skipped []string, err error) {
+	if envFile := b.resolveEnvFile(); envFile != "" {
+	 files = append(files, envFile)
+	}
+
+	name := b.profileName()
+	if name == "" {
+	 return files, nil, nil
+	}
+	effective, err := b.config.EffectiveProfile(name)
+	if err != nil {
+	 return nil, nil, err
+	}
+
+	for _, entry := range effective.EnvFiles {
+	 path, optional := config.ParseEnvFileEntry(entry)
+	 if optional {
+   fullPath := path
+   if !filepath.IsAbs(fullPath) {
+    fullPath = filepath.Join(b.dir, path)
+   }
+   if _, err := os.Stat(fullPath); err != nil {
+    skipped = append(skipped, path)
+    continue
+   }
+	 }
+	 files = append(files, path)
+	}
+	return files, skipped, nil
+}
+
 // resolveFiles resolves the compose files to use based on profile
 func (b *Builder) resolveFiles() ([]string, error) {
 	// If profile specified, use it
@@ -59,7 +130,21 @@ func (b *Builder) resolveFiles() ([]string, error) {
 	 return b.discovery.Files, nil
 	}
 
-	return nil, fmt.Errorf("no compose files found in %s", b.dir)
+	return nil, &config.MissingFileError{
+	 Path:    b.dir,
+	 Message: fmt.Sprintf("no compose files found in %s", b.dir),
+	}
+}
+
+// profileName returns the dox.yaml profile the builder resolves, if any
+func (b *Builder) profileName() string {
+	if b.config == nil {
+	 return ""
+	}
+	if b.profile != "" {
+	 return b.profile
+	}
+	return b.config.GetDefaultProfile()
 }
 
 // resolveEnvFile returns the env file for the current profile
@@ -87,66 +172,70 @@ func (b *Builder) buildBase() ([]string, error) {
 	 return nil, err
 	}
 
-	cmd := []string{"docker", "compose"}
+	cmd := b.runtime.Command()
 	for _, file := range files {
 	 cmd = append(cmd, "-f", file)
 	}
 
-	// Add env file if specified
-	envFile := b.resolveEnvFile()
-	if envFile != "" {
-	 cmd = append(cmd, "--env-file", envFile)
+	// Add project name from the profile
+	if name := b.profileName(); name != "" {
+	 effective, err := b.config.EffectiveProfile(name)
+	 if err != nil {
+   return nil, err
+	 }
+	 if effective.ProjectName != "" {
+   cmd = append(cmd, "-p", effective.ProjectName)
+	 }
 	}
 
-	return cmd, nil
-}
-
-// BuildUp builds the docker compose up command
-func (b *Builder) BuildUp(args []string) ([]string, error) {
-	cmd, err := b.buildBase()
+	// Add env files in order
+	envFiles, _, err := b.EnvFiles()
 	if err != nil {
 	 return nil, err
 	}
+	flags, err := b.runtime.EnvFileFlags(envFiles)
+	if err != nil {
+	 configErr := &config.ConfigError{Err: err}
+	 if b.config != nil {
+   configErr.Path = b.config.Path
+	 }
+	 return nil, configErr
+	}
+	cmd = append(cmd, flags...)
 
-	cmd = append(cmd, "up")
-	cmd = append(cmd, args...)
 	return cmd, nil
 }
 
-// BuildDown builds the docker compose down command
-func (b *Builder) BuildDown(args []string) ([]string, error) {
+// Build builds a command for any compose subcommand
+func (b *Builder) Build(verb string, args []string) ([]string, error) {
 	cmd, err := b.buildBase()
 	if err != nil {
-	 return nil, err
+		return nil, err
 	}
 
-	cmd = append(cmd, "down")
-	cmd = append(cmd, args...)
+	cmd = append(cmd, verb)
+	cmd = append(cmd, b.runtime.AdaptArgs(verb, args)...)
 	return cmd, nil
 }
 
+// BuildUp builds the docker compose up command
+func (b *Builder) BuildUp(args []string) ([]string, error) {
+	return b.Build("up", args)
+}
+
+// BuildDown builds the docker compose down command
+func (b *Builder) BuildDown(args []string) ([]string, error) {
+	return b.Build("down", args)
+}
+
 // BuildPs builds the docker compose ps command
 func (b *Builder) BuildPs(args []string) ([]string, error) {
-	cmd, err := b.buildBase()
-	if err != nil {
-	 return nil, err
-	}
-
-	cmd = append(cmd, "ps")
-	cmd = append(cmd, args...)
-	return cmd, nil
+	return b.Build("ps", args)
 }
 
 // BuildLogs builds the docker compose logs command
 func (b *Builder) BuildLogs(args []string) ([]string, error) {
-	cmd, err := b.buildBase()
-	if err != nil {
-	 return nil, err
-	}
-
-	cmd = append(cmd, "logs")
-	cmd = append(cmd, args...)
-	return cmd, nil
+	return b.Build("logs", args)
 }
 
 // BuildRestart builds the docker compose restart command
@@ -155,14 +244,7 @@ func (b *Builder) BuildRestart(args []string) ([]string, error) {
 	 return nil, fmt.Errorf("restart requires at least one service name")
 	}
 
-	cmd, err := b.buildBase()
-	if err != nil {
-	 return nil, err
-	}
-
-	cmd = append(cmd, "restart")
-	cmd = append(cmd, args...)
-	return cmd, nil
+	return b.Build("restart", args)
 }
 
 // BuildExec builds the docker compose exec command
@@ -171,26 +253,12 @@ func (b *Builder) BuildExec(args []string) ([]string, error) {
 	 return nil, fmt.Errorf("exec requires at least a service name")
 	}
 
-	cmd, err := b.buildBase()
-	if err != nil {
-	 return nil, err
-	}
-
-	cmd = append(cmd, "exec")
-	cmd = append(cmd, args...)
-	return cmd, nil
+	return b.Build("exec", args)
 }
 
 // BuildBuild builds the docker compose build command
 func (b *Builder) BuildBuild(args []string) ([]string, error) {
-	cmd, err := b.buildBase()
-	if err != nil {
-	 return nil, err
-	}
-
-	cmd = append(cmd, "build")
-	cmd = append(cmd, args...)
-	return cmd, nil
+	return b.Build("build", args)
 }
 
 // BuildNuke builds the nuke command (down -v --remove-orphans)
@@ -240,14 +308,17 @@ func (b *Builder) BuildDup() ([][]string, error) {
 
 // BuildStatus builds the status command (enhanced ps)
 func (b *Builder) BuildStatus(args []string) ([]string, error) {
-	cmd, err := b.buildBase()
-	if err != nil {
-	 return nil, err
-	}
+	return b.Build("ps", args)
+}
 
-	cmd = append(cmd, "ps")
-	cmd = append(cmd, args...)
-	return cmd, nil
+// Verb returns the compose subcommand of a command built by this builder,
+// or an empty string if cmd does not start with the builder's base command
+func (b *Builder) Verb(cmd []string) string {
+	base, err := b.buildBase()
+	if err != nil || len(cmd) <= len(base) {
+		return ""
+	}
+	return cmd[len(base)]
 }
 
 // String converts a command slice to a string
@@ -1,6 +1,7 @@
 package compose
 
 import (
+	"os"
 	"path/filepath"
 	"slices"
 	"testing"
@@ -96,6 +97,49 @@ func TestBuildCommand_UpWithProfileAndEnv(t *testing.T) {
 	assert.True(t, sliceContains(cmd, ".env.dev"))
 }
 
+func TestBuildCommand_InheritedProjectName(t *testing.T) {
+	fixtureDir := setupFixture(t, "with-profiles")
+
+	cfg := &config.Config{
+		Profiles: map[string]config.Profile{
+			"base": {ProjectName: "shop"},
+			"dev":  {Extends: config.StringList{"base"}, Slices: []string{"dev"}},
+		},
+	}
+
+	b := NewBuilder(fixtureDir, cfg, "dev")
+	cmd, err := b.BuildUp([]string{})
+	require.NoError(t, err)
+
+	i := slices.Index(cmd, "-p")
+	require.Greater(t, i, -1)
+	assert.Equal(t, "shop", cmd[i+1])
+	assert.Equal(t, "up", cmd[len(cmd)-1])
+}
+
+func TestBuilder_EnvFiles(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "compose.yaml"), []byte("services: {}\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".env.present"), nil, 0644))
+
+	cfg := &config.Config{
+		Profiles: map[string]config.Profile{
+			"base": {EnvFile: ".env", EnvFiles: []string{".env.shared"}},
+			"dev":  {Extends: config.StringList{"base"}, EnvFiles: []string{"?.env.missing", "?.env.present", ".env.alice"}},
+		},
+	}
+
+	b := NewBuilder(dir, cfg, "dev")
+	files, skipped, err := b.EnvFiles()
+	require.NoError(t, err)
+	assert.Equal(t, []string{".env", ".env.shared", ".env.present", ".env.alice"}, files)
+	assert.Equal(t, []string{".env.missing"}, skipped)
+
+	cmd, err := b.BuildUp(nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"--env-file", ".env", "--env-file", ".env.shared", "--env-file", ".env.present", "--env-file", ".env.alice", "up"}, cmd[len(cmd)-9:])
+}
+
 func TestBuildCommand_Down(t *testing.T) {
 	fixtureDir := setupFixture(t, "multi-slice")
 
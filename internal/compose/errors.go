@@ -0,0 +1,179 @@
+package compose
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+
+	"github.com/AkaraChen/dox/internal/config"
+)
+
+// Exit codes used by dox. A failing main command exits with the child's own
+// exit code; the codes below are reserved for failures dox detects itself.
+const (
+	ExitOK             = 0
+	ExitFailure        = 1
+	ExitMissingFile    = 66
+	ExitHookFailed     = 75
+	ExitConfigError    = 78
+	ExitCommandMissing = 127
+	ExitInterrupted    = 130
+)
+
+// ExitError reports a command that ran and exited with a non-zero status
+type ExitError struct {
+	Cmd    []string
+	Code   int
+	Stderr string
+}
+
+func (e *ExitError) Error() string {
+	msg := fmt.Sprintf("'%s' exited with status %d", FormatCommand(e.Cmd), e.Code)
+	if e.Stderr != "" {
+		msg += "\nstderr: " + strings.TrimSpace(e.Stderr)
+	}
+	return msg
+}
+
+// NotFoundError reports a command whose binary is not in PATH
+type NotFoundError struct {
+	Name string
+	Err  error
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("%s not found in PATH", e.Name)
+}
+
+func (e *NotFoundError) Unwrap() error {
+	return e.Err
+}
+
+// HookError reports a hook that failed to run or exited non-zero
+type HookError struct {
+	Hook []string
+	Err  error
+}
+
+func (e *HookError) Error() string {
+	return fmt.Sprintf("hook failed: %s\nError: %v", FormatCommand(e.Hook), e.Err)
+}
+
+func (e *HookError) Unwrap() error {
+	return e.Err
+}
+
+// ParallelError reports the commands of a parallel group that failed, in
+// the order they failed, and the commands stopped because of them. It
+// unwraps to the first failure, which decides the exit code.
+type ParallelError struct {
+	Failed  []error
+	Stopped [][]string
+}
+
+func (e *ParallelError) Error() string {
+	msgs := make([]string, 0, len(e.Failed)+1)
+	for _, err := range e.Failed {
+		msgs = append(msgs, err.Error())
+	}
+	if len(e.Stopped) > 0 {
+		stopped := make([]string, len(e.Stopped))
+		for i, cmd := range e.Stopped {
+			stopped[i] = "'" + FormatCommand(cmd) + "'"
+		}
+		msgs = append(msgs, "stopped: "+strings.Join(stopped, ", "))
+	}
+	return strings.Join(msgs, "\n")
+}
+
+func (e *ParallelError) Unwrap() error {
+	if len(e.Failed) == 0 {
+		return nil
+	}
+	return e.Failed[0]
+}
+
+// errParallelFailed is the cause given to the siblings of a failed
+// parallel step
+var errParallelFailed = errors.New("another parallel command failed")
+
+// InterruptedError reports a plan stopped by a signal
+type InterruptedError struct {
+	Signal os.Signal
+}
+
+func (e *InterruptedError) Error() string {
+	if e.Signal == nil {
+		return "interrupted"
+	}
+	return fmt.Sprintf("interrupted by %s", e.Signal)
+}
+
+// ExitCode returns the conventional 128+N exit code for the signal
+func (e *InterruptedError) ExitCode() int {
+	if sig, ok := e.Signal.(syscall.Signal); ok {
+		return 128 + int(sig)
+	}
+	return ExitInterrupted
+}
+
+// commandError converts an error from os/exec into a typed error
+func commandError(cmd []string, err error, stderr string) error {
+	if err == nil {
+		return nil
+	}
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return &ExitError{Cmd: cmd, Code: exitErr.ExitCode(), Stderr: stderr}
+	}
+	if errors.Is(err, exec.ErrNotFound) && len(cmd) > 0 {
+		return &NotFoundError{Name: cmd[0], Err: err}
+	}
+	return err
+}
+
+// ExitCode returns the process exit code that corresponds to err
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+
+	var interruptedErr *InterruptedError
+	var hookErr *HookError
+	var notFoundErr *NotFoundError
+	var missingErr *config.MissingFileError
+	var configErr *config.ConfigError
+	var exitErr *ExitError
+	var execErr *exec.ExitError
+	var coder exitCoder
+
+	switch {
+	case errors.As(err, &interruptedErr):
+		return interruptedErr.ExitCode()
+	case errors.As(err, &hookErr):
+		return ExitHookFailed
+	case errors.As(err, &notFoundErr):
+		return ExitCommandMissing
+	case errors.As(err, &missingErr):
+		return ExitMissingFile
+	case errors.As(err, &configErr):
+		return ExitConfigError
+	case errors.As(err, &exitErr):
+		return exitErr.Code
+	case errors.As(err, &execErr):
+		return execErr.ExitCode()
+	case errors.As(err, &coder):
+		return coder.ExitCode()
+	}
+	return ExitFailure
+}
+
+// exitCoder is an error that chooses its own exit code
+type exitCoder interface {
+	error
+	ExitCode() int
+}
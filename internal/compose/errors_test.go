@@ -0,0 +1,70 @@
+package compose
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"testing"
+
+	"github.com/AkaraChen/dox/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExitCode(t *testing.T) {
+	assert.Equal(t, ExitOK, ExitCode(nil))
+
+	err := exec.Command("sh", "-c", "exit 42").Run()
+	assert.Equal(t, 42, ExitCode(err))
+	assert.Equal(t, ExitFailure, ExitCode(assert.AnError))
+}
+
+func TestExitCode_Classification(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected int
+	}{
+		{"child exit", fmt.Errorf("command 1 failed: %w", &ExitError{Code: 3}), 3},
+		{"hook", &HookError{Hook: []string{"false"}, Err: &ExitError{Code: 1}}, ExitHookFailed},
+		{"binary missing", &NotFoundError{Name: "docker"}, ExitCommandMissing},
+		{"missing file", &config.MissingFileError{Path: "compose.db.yaml"}, ExitMissingFile},
+		{"config", &config.ConfigError{Path: "dox.yaml", Err: errors.New("bad")}, ExitConfigError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, ExitCode(tt.err))
+		})
+	}
+}
+
+func TestExecutor_RunInteractive_ExitError(t *testing.T) {
+	executor := NewExecutor(false)
+
+	err := executor.RunInteractive([]string{"sh", "-c", "exit 5"})
+	var exitErr *ExitError
+	require.ErrorAs(t, err, &exitErr)
+	assert.Equal(t, 5, exitErr.Code)
+	assert.Equal(t, []string{"sh", "-c", "exit 5"}, exitErr.Cmd)
+}
+
+func TestExecutor_RunCommand_ExitErrorKeepsStderr(t *testing.T) {
+	executor := NewExecutor(false)
+
+	_, err := executor.RunCommand([]string{"sh", "-c", "echo boom >&2; exit 2"})
+	var exitErr *ExitError
+	require.ErrorAs(t, err, &exitErr)
+	assert.Equal(t, 2, exitErr.Code)
+	assert.Contains(t, exitErr.Stderr, "boom")
+	assert.Contains(t, err.Error(), "stderr: boom")
+}
+
+func TestExecutor_RunInteractive_NotFound(t *testing.T) {
+	executor := NewExecutor(false)
+
+	err := executor.RunInteractive([]string{"dox-definitely-not-installed"})
+	var notFoundErr *NotFoundError
+	require.ErrorAs(t, err, &notFoundErr)
+	assert.Equal(t, ExitCommandMissing, ExitCode(err))
+}
@@ -2,28 +2,38 @@ package compose
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
 	"strings"
+	"syscall"
+	"time"
 )
 
+// DefaultGracePeriod is how long an interrupted child may take to exit
+// before it is killed
+const DefaultGracePeriod = 10 * time.Second
+
 // Executor executes commands
 type Executor struct {
-	DryRun bool
-	Dir    string
-	Env    []string
-	Stdout io.Writer
-	Stderr io.Writer
+	DryRun      bool
+	Dir         string
+	Env         []string
+	Stdout      io.Writer
+	Stderr      io.Writer
+	GracePeriod time.Duration
 }
 
 // NewExecutor creates a new command executor
 func NewExecutor(dryRun bool) *Executor {
 	return &Executor{
-	 DryRun: dryRun,
-	 Stdout: os.Stdout,
-	 Stderr: os.Stderr,
+	 DryRun:      dryRun,
+	 Stdout:      os.Stdout,
+	 Stderr:      os.Stderr,
+	 GracePeriod: DefaultGracePeriod,
 	}
 }
 
@@ -63,7 +73,7 @@ func (e *Executor) RunCommand(cmd []string) (string, error) {
 
 	err := c.Run()
 	if err != nil {
-	 return "", fmt.Errorf("command failed: %s\nstderr: %s", err, stderr.String())
+	 return "", commandError(cmd, err, stderr.String())
 	}
 
 	return stdout.String(), nil
@@ -90,7 +100,7 @@ func (e *Executor) RunCommandWithOutput(cmd []string, stdout, stderr io.Writer)
 	 c.Env = append(os.Environ(), e.Env...)
 	}
 
-	return c.Run()
+	return commandError(cmd, c.Run(), "")
 }
 
 // RunCommands executes multiple commands sequentially
@@ -117,35 +127,114 @@ func (e *Executor) RunCommands(commands [][]string) (string, error) {
 
 // RunInteractive executes a command with inherited stdio
 func (e *Executor) RunInteractive(cmd []string) error {
+	return e.RunInteractiveContext(context.Background(), cmd)
+}
+
+// RunInteractiveContext executes a command with inherited stdio until ctx is
+// cancelled. See RunStepContext for how cancellation reaches the child.
+func (e *Executor) RunInteractiveContext(ctx context.Context, cmd []string) error {
+	return e.RunStepContext(ctx, Step{Type: StepShell, Argv: cmd})
+}
+
+// RunInteractiveMultiple executes multiple commands sequentially with inherited stdio
+func (e *Executor) RunInteractiveMultiple(commands [][]string) error {
+	for i, cmd := range commands {
+		if err := e.RunInteractive(cmd); err != nil {
+			return fmt.Errorf("command %d failed: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+// RunStep executes a plan step with inherited stdio.
+// The step's own directory and environment replace the executor's when set.
+func (e *Executor) RunStep(step Step) error {
+	return e.RunStepContext(context.Background(), step)
+}
+
+// RunStepContext executes a plan step until ctx is cancelled.
+// On cancellation the child receives the interrupting signal (or SIGTERM)
+// and is killed if it has not exited after GracePeriod. A cancellation
+// caused by an *InterruptedError is returned as that error.
+func (e *Executor) RunStepContext(ctx context.Context, step Step) error {
 	if e.DryRun {
-		output := FormatCommand(cmd)
-		fmt.Fprintf(e.Stdout, "%s\n", output)
+		fmt.Fprintf(e.Stdout, "%s\n", FormatStep(step))
 		return nil
 	}
 
-	if len(cmd) == 0 {
+	if len(step.Argv) == 0 {
 		return fmt.Errorf("empty command")
 	}
 
-	c := exec.Command(cmd[0], cmd[1:]...)
+	c := exec.Command(step.Argv[0], step.Argv[1:]...)
 	c.Stdin = os.Stdin
 	c.Stdout = e.Stdout
 	c.Stderr = e.Stderr
 	c.Dir = e.Dir
+	if step.Dir != "" {
+		c.Dir = step.Dir
+	}
 
-	if len(e.Env) > 0 {
-		c.Env = append(os.Environ(), e.Env...)
+	env := e.Env
+	if len(step.Env) > 0 {
+		env = step.Env
+	}
+	if len(env) > 0 {
+		c.Env = append(os.Environ(), env...)
 	}
 
-	return c.Run()
+	return commandError(step.Argv, e.wait(ctx, c), "")
 }
 
-// RunInteractiveMultiple executes multiple commands sequentially with inherited stdio
-func (e *Executor) RunInteractiveMultiple(commands [][]string) error {
-	for i, cmd := range commands {
-		if err := e.RunInteractive(cmd); err != nil {
-			return fmt.Errorf("command %d failed: %w", i+1, err)
+// wait starts c and waits for it, forwarding cancellation of ctx
+func (e *Executor) wait(ctx context.Context, c *exec.Cmd) error {
+	if err := c.Start(); err != nil {
+		return err
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- c.Wait() }()
+
+	ctxDone := ctx.Done()
+	var killTimer <-chan time.Time
+	for {
+		select {
+		case err := <-done:
+			if ctx.Err() != nil {
+				var interrupted *InterruptedError
+				if errors.As(context.Cause(ctx), &interrupted) {
+					return interrupted
+				}
+				return ctx.Err()
+			}
+			return err
+		case <-ctxDone:
+			ctxDone = nil
+			e.forward(c.Process, context.Cause(ctx))
+			killTimer = time.After(e.GracePeriod)
+		case <-killTimer:
+			_ = c.Process.Kill()
 		}
 	}
-	return nil
+}
+
+// forward delivers the cancellation cause to the child process. SIGINT is
+// not forwarded when stdin is a terminal: the terminal has already sent it
+// to the whole foreground process group, and a second SIGINT makes docker
+// compose skip its graceful shutdown.
+func (e *Executor) forward(process *os.Process, cause error) {
+	var sig os.Signal = syscall.SIGTERM
+	var interrupted *InterruptedError
+	if errors.As(cause, &interrupted) && interrupted.Signal != nil {
+		sig = interrupted.Signal
+	}
+	if sig == os.Interrupt && stdinIsTerminal() {
+		return
+	}
+	_ = process.Signal(sig)
+}
+
+func stdinIsTerminal() bool {
+	info, err := os.Stdin.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
 }
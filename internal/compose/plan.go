@@ -0,0 +1,268 @@
+package compose
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// StepType identifies what kind of command a plan step runs
+type StepType string
+
+const (
+	// StepHook is a hook command from dox.yaml
+	StepHook StepType = "hook"
+	// StepCompose is a docker compose invocation
+	StepCompose StepType = "compose"
+	// StepShell is a pass-through command from an alias
+	StepShell StepType = "shell"
+	// StepWait pauses the plan for Duration
+	StepWait StepType = "wait"
+	// StepParallel runs its child Steps concurrently
+	StepParallel StepType = "parallel"
+)
+
+// Output formats supported by Plan.Write
+const (
+	OutputText = "text"
+	OutputJSON = "json"
+	OutputSh   = "sh"
+)
+
+// Step is a single command in an execution plan
+type Step struct {
+	Type     StepType `json:"type"`
+	Argv     []string `json:"argv,omitempty"`
+	Dir      string   `json:"cwd,omitempty"`
+	Env      []string `json:"env,omitempty"`
+	Source   string   `json:"source"`
+	Duration string   `json:"duration,omitempty"`
+	Steps    []Step   `json:"steps,omitempty"`
+}
+
+// Plan is an ordered list of steps produced by a dox command.
+// OnFailure steps run only when one of the main steps fails; Finally steps
+// always run, even after an interruption. Notes explain decisions made while
+// building the plan, such as skipped optional env files.
+type Plan struct {
+	Steps     []Step   `json:"steps"`
+	OnFailure []Step   `json:"on_failure,omitempty"`
+	Finally   []Step   `json:"finally,omitempty"`
+	Notes     []string `json:"notes,omitempty"`
+}
+
+// WaitStep creates a step that pauses for the given duration
+func WaitStep(duration, source string) (Step, error) {
+	if _, err := time.ParseDuration(duration); err != nil {
+		return Step{}, fmt.Errorf("invalid wait duration '%s': %w", duration, err)
+	}
+	return Step{Type: StepWait, Duration: duration, Source: source}, nil
+}
+
+// ParallelStep creates a step that runs the given steps concurrently
+func ParallelStep(source string, steps ...Step) Step {
+	return Step{Type: StepParallel, Source: source, Steps: steps}
+}
+
+// NewPlan creates an empty plan
+func NewPlan() *Plan {
+	return &Plan{Steps: []Step{}}
+}
+
+// Add appends steps to the plan
+func (p *Plan) Add(steps ...Step) {
+	p.Steps = append(p.Steps, steps...)
+}
+
+// Commands returns the argv of every command step in order,
+// flattening parallel groups and skipping waits
+func (p *Plan) Commands() [][]string {
+	return collectCommands(nil, p.Steps)
+}
+
+func collectCommands(commands [][]string, steps []Step) [][]string {
+	for _, step := range steps {
+		switch step.Type {
+		case StepWait:
+		case StepParallel:
+			commands = collectCommands(commands, step.Steps)
+		default:
+			commands = append(commands, step.Argv)
+		}
+	}
+	return commands
+}
+
+// FormatStep formats a step for human-readable output
+func FormatStep(step Step) string {
+	switch step.Type {
+	case StepHook:
+		return fmt.Sprintf("  hook: %s", FormatCommand(step.Argv))
+	case StepWait:
+		return fmt.Sprintf("wait %s", step.Duration)
+	case StepParallel:
+		lines := make([]string, 0, len(step.Steps))
+		for _, child := range step.Steps {
+			lines = append(lines, "& "+FormatStep(child))
+		}
+		return strings.Join(lines, "\n")
+	}
+	return FormatCommand(step.Argv)
+}
+
+// Write renders the plan in the given output format
+func (p *Plan) Write(w io.Writer, format string) error {
+	switch format {
+	case "", OutputText:
+		for _, note := range p.Notes {
+			fmt.Fprintf(w, "# %s\n", note)
+		}
+		for _, step := range p.Steps {
+			fmt.Fprintln(w, FormatStep(step))
+		}
+		for _, step := range p.OnFailure {
+			fmt.Fprintf(w, "on failure: %s\n", strings.TrimSpace(FormatStep(step)))
+		}
+		for _, step := range p.Finally {
+			fmt.Fprintf(w, "finally: %s\n", strings.TrimSpace(FormatStep(step)))
+		}
+		return nil
+	case OutputJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(p)
+	case OutputSh:
+		_, err := io.WriteString(w, p.Script())
+		return err
+	default:
+		return fmt.Errorf("unsupported output format '%s' (expected text, json or sh)", format)
+	}
+}
+
+// Script renders the plan as a runnable POSIX shell script
+func (p *Plan) Script() string {
+	var sb strings.Builder
+	sb.WriteString("#!/bin/sh\n")
+	sb.WriteString("set -e\n")
+	for _, note := range p.Notes {
+		sb.WriteString("# ")
+		sb.WriteString(note)
+		sb.WriteString("\n")
+	}
+
+	if len(p.OnFailure) > 0 || len(p.Finally) > 0 {
+		sb.WriteString("\ncleanup() {\n")
+		sb.WriteString("  status=$?\n")
+		if len(p.OnFailure) > 0 {
+			sb.WriteString("  if [ $status -ne 0 ]; then\n")
+			for _, step := range p.OnFailure {
+				sb.WriteString("    ")
+				sb.WriteString(stepCommandLine(step))
+				sb.WriteString(" || true\n")
+			}
+			sb.WriteString("  fi\n")
+		}
+		for _, step := range p.Finally {
+			sb.WriteString("  ")
+			sb.WriteString(stepCommandLine(step))
+			sb.WriteString(" || true\n")
+		}
+		sb.WriteString("  exit $status\n")
+		sb.WriteString("}\n")
+		sb.WriteString("trap cleanup EXIT\n")
+	}
+
+	for _, step := range p.Steps {
+		sb.WriteString("\n# ")
+		sb.WriteString(string(step.Type))
+		if step.Source != "" {
+			sb.WriteString(": ")
+			sb.WriteString(step.Source)
+		}
+		sb.WriteString("\n")
+		writeScriptStep(&sb, step)
+	}
+
+	return sb.String()
+}
+
+func writeScriptStep(sb *strings.Builder, step Step) {
+	switch step.Type {
+	case StepWait:
+		d, _ := time.ParseDuration(step.Duration)
+		fmt.Fprintf(sb, "sleep %g\n", d.Seconds())
+	case StepParallel:
+		for i, child := range step.Steps {
+			fmt.Fprintf(sb, "%s &\npid%d=$!\n", stepCommandLine(child), i)
+		}
+		// Wait for every child before failing, so no command is left running
+		// and the status is the first failure in step order
+		sb.WriteString("failed=0\n")
+		for i := range step.Steps {
+			fmt.Fprintf(sb, "wait $pid%d || { status=$?; [ $failed -ne 0 ] || failed=$status; }\n", i)
+		}
+		sb.WriteString("[ $failed -eq 0 ] || exit $failed\n")
+	default:
+		sb.WriteString(stepCommandLine(step))
+		sb.WriteString("\n")
+	}
+}
+
+// stepCommandLine renders a command step as a subshell with its cwd and env
+func stepCommandLine(step Step) string {
+	var sb strings.Builder
+	sb.WriteString("(")
+	if step.Dir != "" {
+		sb.WriteString("cd ")
+		sb.WriteString(ShellQuote(step.Dir))
+		sb.WriteString(" && ")
+	}
+	if len(step.Env) > 0 {
+		sb.WriteString("env ")
+		for _, kv := range step.Env {
+			sb.WriteString(ShellQuote(kv))
+			sb.WriteString(" ")
+		}
+	}
+	sb.WriteString(ShellJoin(step.Argv))
+	sb.WriteString(")")
+	return sb.String()
+}
+
+// ShellQuote quotes a single argument for POSIX shells.
+// Arguments made only of safe characters are returned unchanged.
+func ShellQuote(arg string) string {
+	if arg == "" {
+		return "''"
+	}
+	safe := true
+	for _, r := range arg {
+		if !isShellSafe(r) {
+			safe = false
+			break
+		}
+	}
+	if safe {
+		return arg
+	}
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
+
+// ShellJoin quotes and joins argv into a single shell command line
+func ShellJoin(argv []string) string {
+	quoted := make([]string, len(argv))
+	for i, arg := range argv {
+		quoted[i] = ShellQuote(arg)
+	}
+	return strings.Join(quoted, " ")
+}
+
+func isShellSafe(r rune) bool {
+	switch {
+	case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		return true
+	}
+	return strings.ContainsRune("-_./:=@%+,", r)
+}
@@ -0,0 +1,119 @@
+package compose
+
+import (
+	"bytes"
+	"encoding/json"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShellQuote(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"compose", "compose"},
+		{"/srv/app/compose.yaml", "/srv/app/compose.yaml"},
+		{"", "''"},
+		{"dox 服务.yaml", "'dox 服务.yaml'"},
+		{"it's", `'it'\''s'`},
+		{"$HOME", "'$HOME'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			assert.Equal(t, tt.expected, ShellQuote(tt.input))
+		})
+	}
+}
+
+func TestPlan_WriteText(t *testing.T) {
+	plan := NewPlan()
+	plan.Add(
+		Step{Type: StepHook, Argv: []string{"echo", "starting"}},
+		Step{Type: StepCompose, Argv: []string{"docker", "compose", "up"}},
+	)
+
+	var buf bytes.Buffer
+	require.NoError(t, plan.Write(&buf, OutputText))
+	assert.Equal(t, "  hook: echo starting\ndocker compose up\n", buf.String())
+}
+
+func TestPlan_WriteNotes(t *testing.T) {
+	plan := NewPlan()
+	plan.Notes = []string{"skipped optional env file .env.local (not found)"}
+	plan.Add(Step{Type: StepCompose, Argv: []string{"docker", "compose", "up"}})
+
+	var buf bytes.Buffer
+	require.NoError(t, plan.Write(&buf, OutputText))
+	assert.Equal(t, "# skipped optional env file .env.local (not found)\ndocker compose up\n", buf.String())
+
+	assert.Contains(t, plan.Script(), "set -e\n# skipped optional env file .env.local (not found)\n")
+}
+
+func TestPlan_WriteJSON(t *testing.T) {
+	plan := NewPlan()
+	plan.Add(Step{
+		Type:   StepCompose,
+		Argv:   []string{"docker", "compose", "-f", "/srv/my app/compose.yaml", "up"},
+		Dir:    "/srv/my app",
+		Env:    []string{"TAG=stable"},
+		Source: "command:up",
+	})
+
+	var buf bytes.Buffer
+	require.NoError(t, plan.Write(&buf, OutputJSON))
+
+	var decoded Plan
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	require.Len(t, decoded.Steps, 1)
+	assert.Equal(t, plan.Steps[0], decoded.Steps[0])
+	assert.Contains(t, buf.String(), `"cwd": "/srv/my app"`)
+}
+
+func TestPlan_WriteUnsupportedFormat(t *testing.T) {
+	var buf bytes.Buffer
+	err := NewPlan().Write(&buf, "yaml")
+	assert.Error(t, err)
+}
+
+func TestPlan_ScriptIsRunnable(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+
+	dir := t.TempDir()
+	plan := NewPlan()
+	plan.Add(
+		Step{Type: StepShell, Argv: []string{"printf", "%s|", "hello world", "it's"}, Dir: dir},
+		Step{Type: StepShell, Argv: []string{"sh", "-c", "printf %s \"$GREETING\""}, Dir: dir, Env: []string{"GREETING=hi there"}},
+	)
+
+	out, err := exec.Command("sh", "-c", plan.Script()).Output()
+	require.NoError(t, err)
+	assert.Equal(t, "hello world|it's|hi there", string(out))
+}
+
+func TestPlan_ScriptParallelStatus(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+
+	marker := filepath.Join(t.TempDir(), "done")
+	plan := NewPlan()
+	plan.Add(ParallelStep("aliases.test[0]",
+		Step{Type: StepShell, Argv: []string{"sh", "-c", "exit 2"}},
+		Step{Type: StepShell, Argv: []string{"sh", "-c", "exit 3"}},
+		Step{Type: StepShell, Argv: []string{"sh", "-c", "sleep 0.2; touch " + marker}},
+	))
+
+	err := exec.Command("sh", "-c", plan.Script()).Run()
+	var exitErr *exec.ExitError
+	require.ErrorAs(t, err, &exitErr)
+	assert.Equal(t, 2, exitErr.ExitCode(), "the first failure in step order")
+	assert.FileExists(t, marker, "every command is waited for")
+}
@@ -0,0 +1,220 @@
+package compose
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/AkaraChen/dox/internal/project"
+)
+
+// Runner executes plans. It is the single place that handles dry-run
+// printing, verbose output, failure hooks, interruption and history recording.
+type Runner struct {
+	Executor    *Executor
+	DryRun      bool
+	Verbose     bool
+	Output      string
+	Stdout      io.Writer
+	HistoryPath string
+	Command     string
+}
+
+// NewRunner creates a runner around an executor
+func NewRunner(executor *Executor) *Runner {
+	return &Runner{
+		Executor: executor,
+		DryRun:   executor.DryRun,
+		Output:   OutputText,
+		Stdout:   os.Stdout,
+	}
+}
+
+// Run executes the plan with SIGINT, SIGTERM and SIGHUP handling
+func (r *Runner) Run(plan *Plan) error {
+	return r.RunContext(context.Background(), plan)
+}
+
+// RunContext prints the plan in dry-run mode, otherwise executes it.
+// When a step fails, the plan's OnFailure steps run before the error is
+// returned. A signal stops the current step (see Executor.RunStepContext)
+// and skips the remaining ones. Finally steps always run.
+func (r *Runner) RunContext(ctx context.Context, plan *Plan) error {
+	if r.DryRun {
+		return plan.Write(r.Stdout, r.Output)
+	}
+	if r.Verbose {
+		for _, note := range plan.Notes {
+			fmt.Fprintf(r.Stdout, "# %s\n", note)
+		}
+	}
+
+	ctx, stop := notifyInterrupt(ctx)
+	err := r.runSteps(ctx, plan.Steps)
+	stop()
+
+	var interrupted *InterruptedError
+	isInterrupted := errors.As(err, &interrupted)
+
+	if err != nil && !isInterrupted && len(plan.OnFailure) > 0 {
+		if hookErr := r.runSteps(context.Background(), plan.OnFailure); hookErr != nil {
+			fmt.Fprintf(r.Executor.Stderr, "on_failure hook failed: %v\n", hookErr)
+		}
+	}
+	if len(plan.Finally) > 0 {
+		if hookErr := r.runSteps(context.Background(), plan.Finally); hookErr != nil {
+			fmt.Fprintf(r.Executor.Stderr, "finally hook failed: %v\n", hookErr)
+		}
+	}
+
+	r.recordHistory(ExitCode(err), isInterrupted)
+	return err
+}
+
+// notifyInterrupt returns a context that is cancelled with an
+// *InterruptedError cause when the process receives SIGINT, SIGTERM or SIGHUP
+func notifyInterrupt(parent context.Context) (context.Context, func()) {
+	ctx, cancel := context.WithCancelCause(parent)
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+
+	go func() {
+		select {
+		case sig := <-sigCh:
+			cancel(&InterruptedError{Signal: sig})
+		case <-ctx.Done():
+		}
+	}()
+
+	return ctx, func() {
+		signal.Stop(sigCh)
+		cancel(nil)
+	}
+}
+
+func (r *Runner) runSteps(ctx context.Context, steps []Step) error {
+	for i, step := range steps {
+		if ctx.Err() != nil {
+			return interruption(ctx)
+		}
+		if err := r.runStep(ctx, step); err != nil {
+			switch step.Type {
+			case StepHook:
+				return &HookError{Hook: step.Argv, Err: err}
+			case StepParallel:
+				return err
+			}
+			return fmt.Errorf("command %d failed: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+func (r *Runner) runStep(ctx context.Context, step Step) error {
+	if r.Verbose {
+		fmt.Fprintln(r.Stdout, FormatStep(step))
+	}
+
+	switch step.Type {
+	case StepWait:
+		d, err := time.ParseDuration(step.Duration)
+		if err != nil {
+			return fmt.Errorf("invalid wait duration '%s': %w", step.Duration, err)
+		}
+		select {
+		case <-time.After(d):
+			return nil
+		case <-ctx.Done():
+			return interruption(ctx)
+		}
+	case StepParallel:
+		return r.runParallel(ctx, step.Steps)
+	}
+
+	return r.Executor.RunStepContext(ctx, step)
+}
+
+// interruption returns the error describing why ctx was cancelled
+func interruption(ctx context.Context) error {
+	var interrupted *InterruptedError
+	if errors.As(context.Cause(ctx), &interrupted) {
+		return interrupted
+	}
+	return ctx.Err()
+}
+
+// runParallel runs steps concurrently. The first failure stops the other
+// steps; every step is waited for and the failures are reported together.
+// A failed hook is reported as a *HookError, like in runSteps.
+func (r *Runner) runParallel(ctx context.Context, steps []Step) error {
+	groupCtx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		parallel ParallelError
+	)
+	for _, step := range steps {
+		wg.Add(1)
+		go func(step Step) {
+			defer wg.Done()
+			err := r.Executor.RunStepContext(groupCtx, step)
+			if err == nil {
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if groupCtx.Err() != nil && len(parallel.Failed) > 0 {
+				parallel.Stopped = append(parallel.Stopped, step.Argv)
+				return
+			}
+			if step.Type == StepHook {
+				err = &HookError{Hook: step.Argv, Err: err}
+			} else {
+				err = fmt.Errorf("parallel command '%s' failed: %w", FormatCommand(step.Argv), err)
+			}
+			parallel.Failed = append(parallel.Failed, err)
+			cancel(errParallelFailed)
+		}(step)
+	}
+	wg.Wait()
+
+	if ctx.Err() != nil {
+		return interruption(ctx)
+	}
+	if len(parallel.Failed) == 0 {
+		return nil
+	}
+	return &parallel
+}
+
+// recordHistory appends the executed command to the history file.
+// Failures to record history never fail the command itself.
+func (r *Runner) recordHistory(exitCode int, interrupted bool) {
+	if r.HistoryPath == "" || r.Command == "" {
+		return
+	}
+
+	dir := r.Executor.Dir
+	if dir == "" {
+		dir, _ = os.Getwd()
+	}
+
+	hist, err := project.LoadHistory(r.HistoryPath)
+	if err == nil {
+		entry := project.NewHistoryEntry(r.Command, dir, exitCode)
+		entry.Interrupted = interrupted
+		hist.AddEntry(entry)
+		err = hist.Save(r.HistoryPath)
+	}
+	if err != nil && r.Verbose {
+		fmt.Fprintf(r.Executor.Stderr, "warning: failed to record history: %v\n", err)
+	}
+}
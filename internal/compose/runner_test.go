@@ -0,0 +1,232 @@
+package compose
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/AkaraChen/dox/internal/project"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRunner(stdout *bytes.Buffer) *Runner {
+	executor := NewExecutor(false)
+	executor.Stdout = stdout
+	executor.Stderr = stdout
+	runner := NewRunner(executor)
+	runner.Stdout = stdout
+	return runner
+}
+
+func TestRunner_StopsOnError(t *testing.T) {
+	var stdout bytes.Buffer
+	runner := newTestRunner(&stdout)
+
+	plan := NewPlan()
+	plan.Add(
+		Step{Type: StepShell, Argv: []string{"echo", "first"}},
+		Step{Type: StepHook, Argv: []string{"false"}},
+		Step{Type: StepShell, Argv: []string{"echo", "never reached"}},
+	)
+
+	err := runner.Run(plan)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "hook failed")
+	assert.Equal(t, "first\n", stdout.String())
+}
+
+func TestRunner_OnFailureRunsOnlyOnError(t *testing.T) {
+	var stdout bytes.Buffer
+	runner := newTestRunner(&stdout)
+
+	plan := NewPlan()
+	plan.Add(Step{Type: StepShell, Argv: []string{"echo", "ok"}})
+	plan.OnFailure = []Step{{Type: StepHook, Argv: []string{"echo", "cleanup"}}}
+
+	require.NoError(t, runner.Run(plan))
+	assert.Equal(t, "ok\n", stdout.String())
+
+	stdout.Reset()
+	plan.Add(Step{Type: StepCompose, Argv: []string{"false"}})
+	require.Error(t, runner.Run(plan))
+	assert.Equal(t, "ok\ncleanup\n", stdout.String())
+}
+
+func TestRunner_DryRunWritesPlan(t *testing.T) {
+	var stdout bytes.Buffer
+	runner := newTestRunner(&stdout)
+	runner.DryRun = true
+
+	plan := NewPlan()
+	plan.Add(Step{Type: StepCompose, Argv: []string{"false"}})
+	plan.OnFailure = []Step{{Type: StepHook, Argv: []string{"echo", "cleanup"}}}
+
+	require.NoError(t, runner.Run(plan))
+	assert.Equal(t, "false\non failure: hook: echo cleanup\n", stdout.String())
+}
+
+func TestRunner_ParallelAndWait(t *testing.T) {
+	var stdout bytes.Buffer
+	runner := newTestRunner(&stdout)
+
+	wait, err := WaitStep("10ms", "aliases.test[1]")
+	require.NoError(t, err)
+
+	plan := NewPlan()
+	plan.Add(
+		ParallelStep("aliases.test[0]",
+			Step{Type: StepShell, Argv: []string{"true"}},
+			Step{Type: StepShell, Argv: []string{"true"}},
+		),
+		wait,
+		Step{Type: StepShell, Argv: []string{"echo", "done"}},
+	)
+
+	require.NoError(t, runner.Run(plan))
+	assert.Equal(t, "done\n", stdout.String())
+	assert.Len(t, plan.Commands(), 3)
+}
+
+func TestRunner_ParallelFailure(t *testing.T) {
+	var stdout bytes.Buffer
+	runner := newTestRunner(&stdout)
+
+	plan := NewPlan()
+	plan.Add(ParallelStep("aliases.test[0]",
+		Step{Type: StepShell, Argv: []string{"true"}},
+		Step{Type: StepShell, Argv: []string{"false"}},
+	))
+
+	err := runner.Run(plan)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "parallel command 'false' failed")
+}
+
+func TestRunner_ParallelStopsSiblings(t *testing.T) {
+	var stdout bytes.Buffer
+	runner := newTestRunner(&stdout)
+	runner.Executor.GracePeriod = time.Second
+
+	plan := NewPlan()
+	plan.Add(ParallelStep("aliases.test[0]",
+		Step{Type: StepShell, Argv: []string{"sleep", "5"}},
+		Step{Type: StepShell, Argv: []string{"sh", "-c", "exit 3"}},
+		Step{Type: StepShell, Argv: []string{"sh", "-c", "sleep 0.1; exit 4"}},
+	))
+
+	start := time.Now()
+	err := runner.Run(plan)
+	require.Error(t, err)
+	assert.Less(t, time.Since(start), 4*time.Second)
+
+	var parallel *ParallelError
+	require.ErrorAs(t, err, &parallel)
+	require.Len(t, parallel.Failed, 1)
+	assert.Contains(t, err.Error(), "parallel command 'sh -c exit 3' failed")
+	assert.Len(t, parallel.Stopped, 2)
+	assert.Equal(t, 3, ExitCode(err))
+}
+
+func TestRunner_ParallelHookFailure(t *testing.T) {
+	var stdout bytes.Buffer
+	runner := newTestRunner(&stdout)
+
+	plan := NewPlan()
+	plan.Add(ParallelStep("aliases.test[0]",
+		Step{Type: StepHook, Argv: []string{"sh", "-c", "exit 3"}},
+		Step{Type: StepShell, Argv: []string{"true"}},
+	))
+
+	err := runner.Run(plan)
+	var hookErr *HookError
+	require.ErrorAs(t, err, &hookErr)
+	assert.Equal(t, []string{"sh", "-c", "exit 3"}, hookErr.Hook)
+	assert.Equal(t, ExitHookFailed, ExitCode(err))
+}
+
+func TestWaitStep_InvalidDuration(t *testing.T) {
+	_, err := WaitStep("soon", "aliases.test[0]")
+	assert.Error(t, err)
+}
+
+func TestRunner_RecordsHistory(t *testing.T) {
+	var stdout bytes.Buffer
+	runner := newTestRunner(&stdout)
+	runner.HistoryPath = filepath.Join(t.TempDir(), "history.yaml")
+	runner.Command = "dox c up"
+
+	plan := NewPlan()
+	plan.Add(Step{Type: StepCompose, Argv: []string{"sh", "-c", "exit 3"}})
+	require.Error(t, runner.Run(plan))
+
+	hist, err := project.LoadHistory(runner.HistoryPath)
+	require.NoError(t, err)
+	require.Len(t, hist.Entries, 1)
+	assert.Equal(t, "dox c up", hist.Entries[0].Command)
+	assert.Equal(t, 3, hist.Entries[0].ExitCode)
+}
+
+func TestRunner_InterruptRunsFinallyAndSkipsRest(t *testing.T) {
+	var stdout bytes.Buffer
+	runner := newTestRunner(&stdout)
+	runner.Executor.GracePeriod = time.Second
+	runner.HistoryPath = filepath.Join(t.TempDir(), "history.yaml")
+	runner.Command = "dox c up"
+
+	plan := NewPlan()
+	plan.Add(
+		Step{Type: StepCompose, Argv: []string{"sleep", "5"}},
+		Step{Type: StepShell, Argv: []string{"echo", "never reached"}},
+	)
+	plan.OnFailure = []Step{{Type: StepHook, Argv: []string{"echo", "on failure"}}}
+	plan.Finally = []Step{{Type: StepHook, Argv: []string{"echo", "cleanup"}}}
+
+	ctx, cancel := context.WithCancelCause(context.Background())
+	time.AfterFunc(50*time.Millisecond, func() {
+		cancel(&InterruptedError{Signal: syscall.SIGTERM})
+	})
+
+	start := time.Now()
+	err := runner.RunContext(ctx, plan)
+	assert.Less(t, time.Since(start), 3*time.Second)
+
+	var interrupted *InterruptedError
+	require.ErrorAs(t, err, &interrupted)
+	assert.Equal(t, 128+int(syscall.SIGTERM), ExitCode(err))
+	assert.Equal(t, "cleanup\n", stdout.String())
+
+	hist, err := project.LoadHistory(runner.HistoryPath)
+	require.NoError(t, err)
+	require.Len(t, hist.Entries, 1)
+	assert.True(t, hist.Entries[0].Interrupted)
+	assert.Equal(t, 143, hist.Entries[0].ExitCode)
+}
+
+func TestExecutor_KillsAfterGracePeriod(t *testing.T) {
+	executor := NewExecutor(false)
+	executor.GracePeriod = 100 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	err := executor.RunInteractiveContext(ctx, []string{"sh", "-c", "trap '' TERM; while :; do :; done"})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Less(t, time.Since(start), 3*time.Second)
+}
+
+func TestRunner_FinallyRunsAfterSuccess(t *testing.T) {
+	var stdout bytes.Buffer
+	runner := newTestRunner(&stdout)
+
+	plan := NewPlan()
+	plan.Add(Step{Type: StepShell, Argv: []string{"echo", "ok"}})
+	plan.Finally = []Step{{Type: StepHook, Argv: []string{"echo", "cleanup"}}}
+
+	require.NoError(t, runner.Run(plan))
+	assert.Equal(t, "ok\ncleanup\n", stdout.String())
+}
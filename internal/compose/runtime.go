@@ -0,0 +1,185 @@
+package compose
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+// Runtime is a container runtime backend that provides the compose command
+type Runtime interface {
+	// Name is the identifier used in dox.yaml, the global config and DOX_RUNTIME
+	Name() string
+	// Command is the base argv that invokes compose
+	Command() []string
+	// EnvFileFlags returns the flags that pass the given env files, or an
+	// error when the backend cannot pass all of them
+	EnvFileFlags(files []string) ([]string, error)
+	// AdaptArgs rewrites subcommand arguments the backend does not support
+	AdaptArgs(verb string, args []string) []string
+	// InfoCommand is the argv that queries the container engine (daemon)
+	InfoCommand() []string
+}
+
+// Runtime names accepted by LookupRuntime
+const (
+	RuntimeDocker        = "docker"
+	RuntimeDockerCompose = "docker-compose"
+	RuntimePodman        = "podman"
+	RuntimePodmanCompose = "podman-compose"
+	RuntimeNerdctl       = "nerdctl"
+)
+
+// composeRuntime describes a compose backend by its capabilities
+type composeRuntime struct {
+	name     string
+	command  []string
+	binary   string
+	engine   string
+	multiEnv bool
+	wait     bool
+}
+
+var runtimes = []*composeRuntime{
+	{name: RuntimeDocker, command: []string{"docker", "compose"}, binary: "docker", engine: "docker", multiEnv: true, wait: true},
+	{name: RuntimeDockerCompose, command: []string{"docker-compose"}, binary: "docker-compose", engine: "docker"},
+	{name: RuntimePodman, command: []string{"podman", "compose"}, binary: "podman", engine: "podman"},
+	{name: RuntimePodmanCompose, command: []string{"podman-compose"}, binary: "podman-compose", engine: "podman"},
+	{name: RuntimeNerdctl, command: []string{"nerdctl", "compose"}, binary: "nerdctl", engine: "nerdctl"},
+}
+
+func (r *composeRuntime) Name() string {
+	return r.name
+}
+
+func (r *composeRuntime) Command() []string {
+	return append([]string{}, r.command...)
+}
+
+func (r *composeRuntime) InfoCommand() []string {
+	return []string{r.engine, "info"}
+}
+
+// EnvFileFlags repeats --env-file. Backends that accept a single
+// --env-file fail for several files rather than silently dropping some.
+func (r *composeRuntime) EnvFileFlags(files []string) ([]string, error) {
+	if len(files) == 0 {
+		return nil, nil
+	}
+	if !r.multiEnv && len(files) > 1 {
+		dropped := files[:len(files)-1]
+		return nil, fmt.Errorf("%s accepts a single --env-file, so %s would be ignored; merge the env files into %s or use the %s runtime",
+			r.name, strings.Join(dropped, ", "), files[len(files)-1], RuntimeDocker)
+	}
+
+	flags := make([]string, 0, len(files)*2)
+	for _, file := range files {
+		flags = append(flags, "--env-file", file)
+	}
+	return flags, nil
+}
+
+// AdaptArgs drops `up --wait` and `--wait-timeout` for backends without them
+func (r *composeRuntime) AdaptArgs(verb string, args []string) []string {
+	if r.wait || verb != "up" {
+		return args
+	}
+
+	adapted := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--wait":
+		case args[i] == "--wait-timeout":
+			i++
+		case strings.HasPrefix(args[i], "--wait-timeout="):
+		default:
+			adapted = append(adapted, args[i])
+		}
+	}
+	return adapted
+}
+
+// DefaultRuntime returns the docker compose (v2) runtime
+func DefaultRuntime() Runtime {
+	return runtimes[0]
+}
+
+// RuntimeNames returns the names of all supported runtimes
+func RuntimeNames() []string {
+	names := make([]string, 0, len(runtimes))
+	for _, r := range runtimes {
+		names = append(names, r.name)
+	}
+	return names
+}
+
+// LookupRuntime returns the runtime with the given name
+func LookupRuntime(name string) (Runtime, error) {
+	for _, r := range runtimes {
+		if r.name == name {
+			return r, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown runtime '%s' (expected one of: %s)", name, strings.Join(RuntimeNames(), ", "))
+}
+
+// DetectRuntime returns the first runtime whose binary is found by lookPath,
+// falling back to docker compose. Runtimes where compose is a subcommand,
+// like the docker compose plugin, must also answer "compose version" when
+// run, since the binary is often installed without it. A nil run skips
+// that probe.
+func DetectRuntime(lookPath func(string) (string, error), run func(argv []string) error) Runtime {
+	for _, r := range runtimes {
+		if _, err := lookPath(r.binary); err != nil {
+			continue
+		}
+		if run != nil && len(r.command) > 1 && run(append(r.Command(), "version")) != nil {
+			continue
+		}
+		return r
+	}
+	return DefaultRuntime()
+}
+
+var (
+	detectOnce sync.Once
+	detected   Runtime
+)
+
+// ResolveRuntime returns the runtime named by the first non-empty name,
+// or the auto-detected runtime when all names are empty. Detection runs
+// the compose plugin probe at most once per process.
+func ResolveRuntime(names ...string) (Runtime, error) {
+	if name := firstName(names); name != "" {
+		return LookupRuntime(name)
+	}
+	detectOnce.Do(func() {
+		detected = DetectRuntime(exec.LookPath, runQuiet)
+	})
+	return detected, nil
+}
+
+// ResolveRuntimeFromPath is ResolveRuntime for plans that are printed but
+// not run: it detects the runtime from PATH alone, without running anything.
+func ResolveRuntimeFromPath(names ...string) (Runtime, error) {
+	if name := firstName(names); name != "" {
+		return LookupRuntime(name)
+	}
+	return DetectRuntime(exec.LookPath, nil), nil
+}
+
+// firstName returns the first non-empty name
+func firstName(names []string) string {
+	for _, name := range names {
+		if name != "" {
+			return name
+		}
+	}
+	return ""
+}
+
+// runQuiet runs argv, discarding its output
+func runQuiet(argv []string) error {
+	return exec.Command(argv[0], argv[1:]...).Run()
+}
@@ -0,0 +1,125 @@
+package compose
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLookupRuntime(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected []string
+	}{
+		{RuntimeDocker, []string{"docker", "compose"}},
+		{RuntimeDockerCompose, []string{"docker-compose"}},
+		{RuntimePodman, []string{"podman", "compose"}},
+		{RuntimePodmanCompose, []string{"podman-compose"}},
+		{RuntimeNerdctl, []string{"nerdctl", "compose"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rt, err := LookupRuntime(tt.name)
+			require.NoError(t, err)
+			assert.Equal(t, tt.name, rt.Name())
+			assert.Equal(t, tt.expected, rt.Command())
+		})
+	}
+
+	_, err := LookupRuntime("containerd")
+	assert.Error(t, err)
+}
+
+func TestDetectRuntime(t *testing.T) {
+	works := func([]string) error { return nil }
+
+	onlyPodman := func(name string) (string, error) {
+		if name == "podman" {
+			return "/usr/bin/podman", nil
+		}
+		return "", errors.New("not found")
+	}
+	assert.Equal(t, RuntimePodman, DetectRuntime(onlyPodman, works).Name())
+
+	nothing := func(string) (string, error) { return "", errors.New("not found") }
+	assert.Equal(t, RuntimeDocker, DetectRuntime(nothing, works).Name())
+}
+
+func TestDetectRuntime_DockerWithoutComposePlugin(t *testing.T) {
+	everything := func(name string) (string, error) { return "/usr/bin/" + name, nil }
+	var probed [][]string
+	noPlugin := func(argv []string) error {
+		probed = append(probed, argv)
+		if argv[0] == "docker" {
+			return errors.New("'compose' is not a docker command")
+		}
+		return nil
+	}
+
+	assert.Equal(t, RuntimeDockerCompose, DetectRuntime(everything, noPlugin).Name())
+	assert.Equal(t, [][]string{{"docker", "compose", "version"}}, probed, "standalone binaries are not probed")
+
+	// Without a probe the runtime is chosen from PATH alone
+	assert.Equal(t, RuntimeDocker, DetectRuntime(everything, nil).Name())
+}
+
+func TestResolveRuntime_FirstNameWins(t *testing.T) {
+	rt, err := ResolveRuntime("", RuntimeNerdctl, RuntimePodman)
+	require.NoError(t, err)
+	assert.Equal(t, RuntimeNerdctl, rt.Name())
+
+	rt, err = ResolveRuntimeFromPath("", RuntimePodman)
+	require.NoError(t, err)
+	assert.Equal(t, RuntimePodman, rt.Name())
+}
+
+func TestRuntime_EnvFileFlags(t *testing.T) {
+	files := []string{".env", ".env.local"}
+
+	docker, _ := LookupRuntime(RuntimeDocker)
+	flags, err := docker.EnvFileFlags(files)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"--env-file", ".env", "--env-file", ".env.local"}, flags)
+
+	legacy, _ := LookupRuntime(RuntimeDockerCompose)
+	flags, err = legacy.EnvFileFlags(files[1:])
+	require.NoError(t, err)
+	assert.Equal(t, []string{"--env-file", ".env.local"}, flags)
+	flags, err = legacy.EnvFileFlags(nil)
+	require.NoError(t, err)
+	assert.Empty(t, flags)
+
+	_, err = legacy.EnvFileFlags(files)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "docker-compose accepts a single --env-file, so .env would be ignored")
+}
+
+func TestRuntime_AdaptArgsDropsWait(t *testing.T) {
+	args := []string{"-d", "--wait", "--wait-timeout", "30", "--wait-timeout=10", "api"}
+
+	docker, _ := LookupRuntime(RuntimeDocker)
+	assert.Equal(t, args, docker.AdaptArgs("up", args))
+
+	podman, _ := LookupRuntime(RuntimePodmanCompose)
+	assert.Equal(t, []string{"-d", "api"}, podman.AdaptArgs("up", args))
+	assert.Equal(t, []string{"--wait"}, podman.AdaptArgs("logs", []string{"--wait"}))
+}
+
+func TestBuilder_SetRuntime(t *testing.T) {
+	fixtureDir := setupFixture(t, "with-env")
+
+	b := NewBuilder(fixtureDir, nil, "")
+	rt, err := LookupRuntime(RuntimePodmanCompose)
+	require.NoError(t, err)
+	b.SetRuntime(rt)
+
+	cmd, err := b.BuildUp([]string{"-d", "--wait"})
+	require.NoError(t, err)
+	assert.Equal(t, "podman-compose", cmd[0])
+	assert.Equal(t, "-f", cmd[1])
+	assert.Equal(t, []string{"up", "-d"}, cmd[len(cmd)-2:])
+	assert.Equal(t, "up", b.Verb(cmd))
+}
@@ -0,0 +1,126 @@
+package compose
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SplitWords splits a command line into words the way a POSIX shell does,
+// without expanding anything: words are separated by blanks, single quotes
+// keep their content literally, and in double quotes a backslash only
+// escapes $, `, " and \. Outside quotes a backslash escapes the next
+// character. "ShellJoin(SplitWords(s))" gives back an equivalent line.
+func SplitWords(s string) ([]string, error) {
+	words := []string{}
+	err := scanWords(s, false, func(word string, _ bool) {
+		words = append(words, word)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return words, nil
+}
+
+// SplitChain splits an alias definition into the commands chained with &&
+// and, within each link, the commands joined with a single & to run in
+// parallel. The words follow SplitWords, and & is only an operator outside
+// quotes, so "echo 'a && b'" is a single command. Empty commands are dropped.
+func SplitChain(s string) ([][][]string, error) {
+	var chain [][][]string
+	var group [][]string
+	var cmd []string
+	endCmd := func() {
+		if len(cmd) > 0 {
+			group = append(group, cmd)
+		}
+		cmd = nil
+	}
+	endGroup := func() {
+		endCmd()
+		if len(group) > 0 {
+			chain = append(chain, group)
+		}
+		group = nil
+	}
+
+	err := scanWords(s, true, func(word string, op bool) {
+		switch {
+		case !op:
+			cmd = append(cmd, word)
+		case word == "&&":
+			endGroup()
+		default:
+			endCmd()
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	endGroup()
+	return chain, nil
+}
+
+// scanWords calls emit for each word of s and, when operators is set, for
+// each & or && outside quotes with op set
+func scanWords(s string, operators bool, emit func(word string, op bool)) error {
+	var word strings.Builder
+	inWord := false
+	endWord := func() {
+		if inWord {
+			emit(word.String(), false)
+			word.Reset()
+			inWord = false
+		}
+	}
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n':
+			endWord()
+		case c == '&' && operators:
+			endWord()
+			if i+1 < len(s) && s[i+1] == '&' {
+				i++
+				emit("&&", true)
+			} else {
+				emit("&", true)
+			}
+		case c == '\'':
+			end := strings.IndexByte(s[i+1:], '\'')
+			if end < 0 {
+				return fmt.Errorf("unterminated single quote in %q", s)
+			}
+			word.WriteString(s[i+1 : i+1+end])
+			i += end + 1
+			inWord = true
+		case c == '"':
+			closed := false
+			for i++; i < len(s); i++ {
+				if s[i] == '"' {
+					closed = true
+					break
+				}
+				if s[i] == '\\' && i+1 < len(s) && strings.IndexByte("$`\"\\", s[i+1]) >= 0 {
+					i++
+				}
+				word.WriteByte(s[i])
+			}
+			if !closed {
+				return fmt.Errorf("unterminated double quote in %q", s)
+			}
+			inWord = true
+		case c == '\\':
+			if i+1 < len(s) {
+				i++
+				word.WriteByte(s[i])
+			}
+			inWord = true
+		default:
+			word.WriteByte(c)
+			inWord = true
+		}
+	}
+	endWord()
+	return nil
+}
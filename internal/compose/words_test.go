@@ -0,0 +1,68 @@
+package compose
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitWords(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"", []string{}},
+		{"  echo   hello ", []string{"echo", "hello"}},
+		{`echo "Starting services..."`, []string{"echo", "Starting services..."}},
+		{`echo 'it''s' "a \"b\" \d"`, []string{"echo", "its", `a "b" \d`}},
+		{`echo 'Post up: $HOME'`, []string{"echo", "Post up: $HOME"}},
+		{`printf a\ b ''`, []string{"printf", "a b", ""}},
+		{`curl -d "x=1&y=2"`, []string{"curl", "-d", "x=1&y=2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			words, err := SplitWords(tt.input)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, words)
+
+			again, err := SplitWords(ShellJoin(words))
+			require.NoError(t, err)
+			assert.Equal(t, words, again, "ShellJoin round-trips")
+		})
+	}
+}
+
+func TestSplitWords_Unterminated(t *testing.T) {
+	_, err := SplitWords(`echo 'oops`)
+	assert.EqualError(t, err, `unterminated single quote in "echo 'oops"`)
+
+	_, err = SplitWords(`echo "oops`)
+	assert.EqualError(t, err, `unterminated double quote in "echo \"oops"`)
+}
+
+func TestSplitChain(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected [][][]string
+	}{
+		{"up -d", [][][]string{{{"up", "-d"}}}},
+		{"  down   &&  up  ", [][][]string{{{"down"}}, {{"up"}}}},
+		{"up -d db & up -d cache && logs", [][][]string{{{"up", "-d", "db"}, {"up", "-d", "cache"}}, {{"logs"}}}},
+		{"down&&up -d&ps", [][][]string{{{"down"}}, {{"up", "-d"}, {"ps"}}}},
+		{`curl -d "x=1&y=2" && echo 'a && b' a\&b`, [][][]string{{{"curl", "-d", "x=1&y=2"}}, {{"echo", "a && b", "a&b"}}}},
+		{"&& up &", [][][]string{{{"up"}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			chain, err := SplitChain(tt.input)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, chain)
+		})
+	}
+
+	_, err := SplitChain(`echo "oops && up`)
+	assert.Error(t, err)
+}
@@ -6,6 +6,8 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
+
+	"gopkg.in/yaml.v3"
 )
 
 // Discovery holds auto-discovered compose files
@@ -15,15 +17,35 @@ type Discovery struct {
 	Files    []string          // ordered list of all files
 }
 
+// BaseFileNames are the base compose files in order of preference
+var BaseFileNames = []string{"compose.yaml", "docker-compose.yaml", "compose.yml", "docker-compose.yml"}
+
 // Config is the main dox.yaml configuration
 type Config struct {
+	Path       string                 `yaml:"-"`
+	// Files are the files merged into this config, included files first
+	Files      []string               `yaml:"-"`
+	// Overrides are the local override files merged over dox.yaml
+	Overrides  []string               `yaml:"-"`
+	// Warnings are problems found while loading, such as unset variables
+	Warnings   []string               `yaml:"-"`
+	Include    StringList             `yaml:"include"`
 	Version    int                    `yaml:"version"`
+	// DefaultProfile is the profile used without --profile (version 2)
+	DefaultProfile string             `yaml:"default_profile"`
+	// Slices maps slice names to their compose files (version 2). Slices
+	// not listed are discovered as compose.NAME.yaml.
+	Slices     map[string]StringList  `yaml:"slices"`
+	Runtime    string                 `yaml:"runtime"`
 	Discovery  DiscoveryConfig        `yaml:"discovery"`
 	Profiles   map[string]Profile     `yaml:"profiles"`
 	EnvFiles   map[string]string      `yaml:"env_files"`
 	Defaults   Defaults               `yaml:"defaults"`
 	Aliases    map[string]string      `yaml:"aliases"`
 	Hooks      map[string][]string    `yaml:"hooks"`
+	Lint       LintConfig             `yaml:"lint"`
+	// Vars are variables for ${VAR} interpolation in the other values
+	Vars       map[string]string      `yaml:"vars"`
 }
 
 // DiscoveryConfig configures auto-discovery behavior
@@ -33,12 +55,49 @@ type DiscoveryConfig struct {
 	Base    string `yaml:"base"`
 }
 
-// Profile defines a set of compose slices
+// LintConfig configures dox lint. Rules maps a rule ID to its severity:
+// error, warning or off.
+type LintConfig struct {
+	Rules map[string]string `yaml:"rules"`
+}
+
+// Profile defines a set of compose slices and the settings used with them.
+// A slice prefixed with "!" drops that slice if an extended profile added it.
+// See EffectiveProfile for how fields are inherited through Extends.
 type Profile struct {
-	Slices   []string `yaml:"slices"`
-	EnvFile  string   `yaml:"env_file"`
-	Env      string   `yaml:"env"`
-	Extends  string   `yaml:"extends"`
+	Slices       []string            `yaml:"slices"`
+	EnvFile      string              `yaml:"env_file"`
+	Env          string              `yaml:"env"`
+	EnvFiles     []string            `yaml:"env_files"`
+	Extends      StringList          `yaml:"extends"`
+	Environment  map[string]string   `yaml:"environment"`
+	Hooks        map[string][]string `yaml:"hooks"`
+	Aliases      map[string]string   `yaml:"aliases"`
+	ProjectName  string              `yaml:"project_name"`
+	Context      string              `yaml:"context"`
+	Reset        StringList          `yaml:"reset"`
+}
+
+// StringList is a list that may also be written as a single string in YAML
+type StringList []string
+
+// UnmarshalYAML accepts a scalar or a sequence of scalars
+func (l *StringList) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+	 if value.Value == "" {
+   *l = nil
+   return nil
+	 }
+	 *l = StringList{value.Value}
+	 return nil
+	}
+
+	var list []string
+	if err := value.Decode(&list); err != nil {
+	 return err
+	}
+	*l = list
+	return nil
 }
 
 // Defaults defines default behavior
@@ -59,10 +118,8 @@ func DiscoverFiles(dir string) (*Discovery, error) {
 		Slices: make(map[string]string),
 	}
 
-	// Base files to look for (in order of preference)
-	baseFiles := []string{"compose.yaml", "docker-compose.yaml", "compose.yml", "docker-compose.yml"}
-
-	for _, base := range baseFiles {
+	// Base files to look for
+	for _, base := range BaseFileNames {
 	 fullPath := filepath.Join(dir, base)
 	 if _, err := os.Stat(fullPath); err == nil {
    d.BaseFile = fullPath
@@ -130,68 +187,21 @@ func DiscoverFiles(dir string) (*Discovery, error) {
 
 // ResolveProfile resolves a profile to a list of compose files
 func (c *Config) ResolveProfile(profileName string, discovery *Discovery) ([]string, string, error) {
-	profile, exists := c.Profiles[profileName]
-	if !exists {
-	 return nil, "", fmt.Errorf("profile '%s' not found", profileName)
-	}
-
-	// Handle inheritance
-	slices := append([]string{}, profile.Slices...)
-	currentExtends := profile.Extends
-	visited := map[string]bool{profileName: true}
-
-	for currentExtends != "" {
-	 if visited[currentExtends] {
-   return nil, "", fmt.Errorf("circular profile inheritance detected")
-	 }
-	 visited[currentExtends] = true
-
-	 parentProfile, exists := c.Profiles[currentExtends]
-	 if !exists {
-   return nil, "", fmt.Errorf("profile '%s' extends non-existent profile '%s'", profileName, currentExtends)
-	 }
-	 // Prepend parent slices
-	 slices = append(parentProfile.Slices, slices...)
-	 currentExtends = parentProfile.Extends
-	}
-
-	files := []string{}
-	if discovery.BaseFile != "" {
-	 files = append(files, discovery.BaseFile)
-	}
-
-	// Resolve slices to files, de-duplicating
-	seen := map[string]bool{}
-	for _, sliceName := range slices {
-	 if seen[sliceName] {
-   continue
-	 }
-	 seen[sliceName] = true
-
-	 sliceFile, exists := discovery.Slices[sliceName]
-	 if !exists {
-   return nil, "", fmt.Errorf("slice file 'compose.%s.yaml' not found for profile '%s'", sliceName, profileName)
-	 }
-	 files = append(files, sliceFile)
+	trace, err := c.TraceProfile(profileName, discovery)
+	if err != nil {
+	 return nil, "", err
 	}
-
-	// Resolve env file
-	envFile := ""
-	if profile.EnvFile != "" {
-	 envFile = profile.EnvFile
-	} else if profile.Env != "" {
-	 if env, ok := c.EnvFiles[profile.Env]; ok {
-   envFile = env
-	 }
+	if trace.Files == nil {
+	 trace.Files = []string{}
 	}
-
-	return files, envFile, nil
+	return trace.Files, trace.EnvFile, nil
 }
 
-// GetDefaultProfile returns the default profile name
+// GetDefaultProfile returns the default profile name from default_profile,
+// or the legacy defaults.profile
 func (c *Config) GetDefaultProfile() string {
-	if c.Defaults.Profile != "" {
-	 return c.Defaults.Profile
+	if c.DefaultProfile != "" {
+	 return c.DefaultProfile
 	}
-	return ""
+	return c.Defaults.Profile
 }
@@ -216,7 +216,7 @@ func TestResolveProfile_Inheritance(t *testing.T) {
    },
    "full": {
     Slices:  []string{"dev"},
-    Extends: "base",
+    Extends: StringList{"base"},
    },
 	 },
 	}
@@ -242,8 +242,8 @@ func TestResolveProfile_Inheritance(t *testing.T) {
 func TestResolveProfile_CircularInheritance(t *testing.T) {
 	c := &Config{
 	 Profiles: map[string]Profile{
-   "a": {Slices: []string{}, Extends: "b"},
-   "b": {Slices: []string{}, Extends: "a"},
+   "a": {Slices: []string{}, Extends: StringList{"b"}},
+   "b": {Slices: []string{}, Extends: StringList{"a"}},
 	 },
 	}
 
@@ -262,7 +262,7 @@ func TestResolveProfile_DeduplicateSlices(t *testing.T) {
    "base": {Slices: []string{"db"}},
    "full": {
     Slices:  []string{"db", "dev"},
-    Extends: "base",
+    Extends: StringList{"base"},
    },
 	 },
 	}
@@ -338,3 +338,121 @@ func TestGetDefaultProfile(t *testing.T) {
 	 })
 	}
 }
+
+func TestResolveProfile_TypedErrors(t *testing.T) {
+	cfg := &Config{
+		Path: "dox.yaml",
+		Profiles: map[string]Profile{
+			"dev": {Slices: []string{"missing"}},
+		},
+	}
+	discovery := &Discovery{Slices: map[string]string{}}
+
+	_, _, err := cfg.ResolveProfile("nope", discovery)
+	var configErr *ConfigError
+	require.ErrorAs(t, err, &configErr)
+	assert.Equal(t, "dox.yaml", configErr.Path)
+
+	_, _, err = cfg.ResolveProfile("dev", discovery)
+	var missingErr *MissingFileError
+	require.ErrorAs(t, err, &missingErr)
+	assert.Equal(t, "missing", missingErr.Slice)
+	assert.Equal(t, "dev", missingErr.Profile)
+}
+
+func TestResolveProfile_MultipleInheritance(t *testing.T) {
+	c := &Config{
+		Profiles: map[string]Profile{
+			"base":       {Slices: []string{"db", "mailhog"}},
+			"prod":       {Slices: []string{"prod"}, Extends: StringList{"base"}},
+			"monitoring": {Slices: []string{"grafana", "db"}, Extends: StringList{"base"}},
+			"prod-full":  {Slices: []string{"debug", "!mailhog"}, Extends: StringList{"prod", "monitoring"}},
+		},
+	}
+	d := &Discovery{
+		BaseFile: "compose.yaml",
+		Slices: map[string]string{
+			"db":      "compose.db.yaml",
+			"mailhog": "compose.mailhog.yaml",
+			"prod":    "compose.prod.yaml",
+			"grafana": "compose.grafana.yaml",
+			"debug":   "compose.debug.yaml",
+		},
+	}
+
+	files, _, err := c.ResolveProfile("prod-full", d)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"compose.yaml", "compose.db.yaml", "compose.prod.yaml", "compose.grafana.yaml", "compose.debug.yaml"}, files)
+
+	trace, err := c.TraceProfile("prod-full", d)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"base", "prod", "monitoring", "prod-full"}, trace.Order)
+}
+
+func TestResolveProfile_ExcludedSliceReadded(t *testing.T) {
+	c := &Config{
+		Profiles: map[string]Profile{
+			"base": {Slices: []string{"db", "mailhog"}},
+			"dev":  {Slices: []string{"!mailhog", "dev", "mailhog"}, Extends: StringList{"base"}},
+		},
+	}
+	d := &Discovery{Slices: map[string]string{
+		"db":      "compose.db.yaml",
+		"dev":     "compose.dev.yaml",
+		"mailhog": "compose.mailhog.yaml",
+	}}
+
+	files, _, err := c.ResolveProfile("dev", d)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"compose.db.yaml", "compose.dev.yaml", "compose.mailhog.yaml"}, files)
+}
+
+func TestResolveProfile_CyclePath(t *testing.T) {
+	c := &Config{
+		Profiles: map[string]Profile{
+			"app":   {Extends: StringList{"base", "a"}},
+			"base":  {},
+			"a":     {Extends: StringList{"b"}},
+			"b":     {Extends: StringList{"base", "c"}},
+			"c":     {Extends: StringList{"a"}},
+			"other": {Extends: StringList{"missing"}},
+		},
+	}
+	d := &Discovery{}
+
+	_, _, err := c.ResolveProfile("app", d)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "circular profile inheritance: a -> b -> c -> a")
+
+	_, _, err = c.ResolveProfile("other", d)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "profile 'other' extends non-existent profile 'missing'")
+}
+
+func TestResolveProfile_InheritedEnvFile(t *testing.T) {
+	c := &Config{
+		EnvFiles: map[string]string{"prod": ".env.prod"},
+		Profiles: map[string]Profile{
+			"prod":       {Env: "prod"},
+			"monitoring": {EnvFile: ".env.monitoring"},
+			"tools":      {},
+			"prod-tools": {Extends: StringList{"prod", "tools"}},
+			"conflict":   {Extends: StringList{"prod", "monitoring"}},
+			"resolved":   {Extends: StringList{"prod", "monitoring"}, EnvFile: ".env.resolved"},
+		},
+	}
+	d := &Discovery{}
+
+	_, envFile, err := c.ResolveProfile("prod-tools", d)
+	require.NoError(t, err)
+	assert.Equal(t, ".env.prod", envFile)
+
+	_, _, err = c.ResolveProfile("conflict", d)
+	var configErr *ConfigError
+	require.ErrorAs(t, err, &configErr)
+	assert.Contains(t, err.Error(), "inherits conflicting env files '.env.prod' (from 'prod') and '.env.monitoring' (from 'monitoring')")
+
+	_, envFile, err = c.ResolveProfile("resolved", d)
+	require.NoError(t, err)
+	assert.Equal(t, ".env.resolved", envFile)
+}
@@ -0,0 +1,41 @@
+package config
+
+import "fmt"
+
+// ConfigError reports a dox.yaml that cannot be read, parsed or resolved
+type ConfigError struct {
+	Path string
+	Err  error
+}
+
+func (e *ConfigError) Error() string {
+	if e.Path == "" {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("%s: %v", e.Path, e.Err)
+}
+
+func (e *ConfigError) Unwrap() error {
+	return e.Err
+}
+
+// configErrorf creates a ConfigError for the config loaded from path
+func configErrorf(path, format string, args ...any) error {
+	return &ConfigError{Path: path, Err: fmt.Errorf(format, args...)}
+}
+
+// MissingFileError reports a compose or env file that does not exist.
+// Slice and Profile name the config elements that referenced it, if any.
+type MissingFileError struct {
+	Path    string
+	Slice   string
+	Profile string
+	Message string
+}
+
+func (e *MissingFileError) Error() string {
+	if e.Message != "" {
+		return e.Message
+	}
+	return fmt.Sprintf("file '%s' not found", e.Path)
+}
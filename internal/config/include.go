@@ -0,0 +1,181 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigPathEnv lists directories searched for included files that are not
+// found next to the file including them
+const ConfigPathEnv = "DOX_CONFIG_PATH"
+
+// loader reads a config file and the files it includes, remembering which
+// file each YAML node came from so errors point at the real location
+type loader struct {
+	sources map[*yaml.Node]string
+	files   []string // files in merge order, included files first
+	in      *interpolator
+}
+
+// newLoader creates a loader for the dox.yaml in dir. selected is the
+// profile DOX_PROFILE resolves to, if already known.
+func newLoader(dir, selected string) *loader {
+	l := &loader{sources: map[*yaml.Node]string{}}
+	l.in = newInterpolator(dir, l.sources, selected)
+	return l
+}
+
+// load returns the top-level mapping of path merged over the files it
+// includes. stack holds the files being loaded, to report include cycles.
+func (l *loader) load(path string, stack []string) (*yaml.Node, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, &ConfigError{Path: path, Err: fmt.Errorf("failed to read config file: %w", err)}
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, &ConfigError{Path: path, Err: fmt.Errorf("failed to parse config file: %w", err)}
+	}
+	root := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	if doc.Kind == yaml.DocumentNode && len(doc.Content) > 0 {
+		// Decode each file on its own so type errors name the right file
+		var check Config
+		if err := doc.Decode(&check); err != nil {
+			return nil, &ConfigError{Path: path, Err: fmt.Errorf("failed to parse config file: %w", err)}
+		}
+		root = doc.Content[0]
+	}
+	l.record(root, path)
+
+	stack = append(slices.Clone(stack), path)
+	merged := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	for _, entry := range includeEntries(root) {
+		// vars are only known after merging, so entries see the environment
+		if err := l.in.expandNode(entry); err != nil {
+			return nil, err
+		}
+		included, err := resolveInclude(entry.Value, filepath.Dir(path))
+		if err != nil {
+			return nil, &ConfigError{Path: path, Err: fmt.Errorf("line %d: %w", entry.Line, err)}
+		}
+		if slices.ContainsFunc(stack, func(p string) bool { return sameFile(p, included) }) {
+			cycle := append(slices.Clone(stack), included)
+			return nil, &ConfigError{Path: path, Err: fmt.Errorf("line %d: include cycle: %s", entry.Line, strings.Join(cycle, " -> "))}
+		}
+		node, err := l.load(included, stack)
+		if err != nil {
+			return nil, err
+		}
+		merged = mergeNodes(merged, node)
+	}
+
+	l.files = append(l.files, path)
+	return mergeNodes(merged, root), nil
+}
+
+// record remembers path as the source of node and everything under it
+func (l *loader) record(node *yaml.Node, path string) {
+	l.sources[node] = path
+	for _, child := range node.Content {
+		l.record(child, path)
+	}
+}
+
+// includeEntries returns the scalar nodes of the include key, which may be
+// a single path or a list
+func includeEntries(root *yaml.Node) []*yaml.Node {
+	value := mappingValue(root, "include")
+	switch {
+	case value == nil:
+		return nil
+	case value.Kind == yaml.ScalarNode && value.Value != "":
+		return []*yaml.Node{value}
+	case value.Kind == yaml.SequenceNode:
+		return value.Content
+	}
+	return nil
+}
+
+// resolveInclude finds an included file. Paths starting with ~/ are in the
+// home directory; relative paths are looked up next to the including file,
+// then in each directory of DOX_CONFIG_PATH.
+func resolveInclude(entry, dir string) (string, error) {
+	if rest, ok := strings.CutPrefix(entry, "~/"); ok {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("include '%s': %w", entry, err)
+		}
+		entry = filepath.Join(home, rest)
+	}
+	if filepath.IsAbs(entry) {
+		if _, err := os.Stat(entry); err != nil {
+			return "", fmt.Errorf("include '%s' not found", entry)
+		}
+		return entry, nil
+	}
+
+	searched := []string{dir}
+	for _, searchDir := range filepath.SplitList(os.Getenv(ConfigPathEnv)) {
+		if searchDir != "" {
+			searched = append(searched, searchDir)
+		}
+	}
+	for _, searchDir := range searched {
+		path := filepath.Join(searchDir, entry)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("include '%s' not found in %s", entry, strings.Join(searched, ", "))
+}
+
+// mergeNodes merges src over dst. Mappings are merged key by key; any other
+// value in src, lists included, replaces the one in dst.
+func mergeNodes(dst, src *yaml.Node) *yaml.Node {
+	if dst == nil || dst.Kind != yaml.MappingNode || src.Kind != yaml.MappingNode {
+		return src
+	}
+	for i := 0; i+1 < len(src.Content); i += 2 {
+		key, value := src.Content[i], src.Content[i+1]
+		if j := mappingIndex(dst, key.Value); j >= 0 {
+			dst.Content[j+1] = mergeNodes(dst.Content[j+1], value)
+		} else {
+			dst.Content = append(dst.Content, key, value)
+		}
+	}
+	return dst
+}
+
+// mappingIndex returns the index of key in a mapping node's content, or -1
+func mappingIndex(node *yaml.Node, key string) int {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// mappingValue returns the value of key in a mapping node, or nil
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if i := mappingIndex(node, key); i >= 0 {
+		return node.Content[i+1]
+	}
+	return nil
+}
+
+// sameFile reports whether two paths name the same file
+func sameFile(a, b string) bool {
+	if a == b {
+		return true
+	}
+	infoA, errA := os.Stat(a)
+	infoB, errB := os.Stat(b)
+	return errA == nil && errB == nil && os.SameFile(infoA, infoB)
+}
@@ -0,0 +1,138 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeConfigFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+}
+
+func TestLoadConfig_Include(t *testing.T) {
+	dir := t.TempDir()
+	writeConfigFiles(t, dir, map[string]string{
+		"shared/team.yaml": `
+vars:
+  REGISTRY: ghcr.io/acme
+aliases:
+  dev: up -d
+  logs: logs -f
+hooks:
+  pre_up: ["echo team"]
+profiles:
+  dev:
+    slices: [dev]
+    project_name: team
+`,
+		"shared/runtime.yaml": "runtime: podman\n",
+		"dox.yaml": `
+include: [shared/team.yaml, shared/runtime.yaml]
+aliases:
+  logs: logs --tail 100
+  push: push ${REGISTRY}/api
+hooks:
+  pre_up: ["echo repo"]
+profiles:
+  dev:
+    project_name: shop
+`,
+	})
+
+	config, err := LoadConfig(filepath.Join(dir, "dox.yaml"))
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		filepath.Join(dir, "shared/team.yaml"),
+		filepath.Join(dir, "shared/runtime.yaml"),
+		filepath.Join(dir, "dox.yaml"),
+	}, config.Files)
+	assert.Equal(t, StringList{"shared/team.yaml", "shared/runtime.yaml"}, config.Include)
+	assert.Equal(t, "podman", config.Runtime)
+	assert.Equal(t, map[string]string{
+		"dev":  "up -d",
+		"logs": "logs --tail 100",
+		"push": "push ghcr.io/acme/api",
+	}, config.Aliases, "mappings merge, later files win")
+	assert.Equal(t, []string{"echo repo"}, config.Hooks["pre_up"], "lists are replaced")
+	assert.Equal(t, []string{"dev"}, config.Profiles["dev"].Slices)
+	assert.Equal(t, "shop", config.Profiles["dev"].ProjectName)
+}
+
+func TestLoadConfig_IncludeSearchPath(t *testing.T) {
+	home, shared, project := t.TempDir(), t.TempDir(), t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv(ConfigPathEnv, filepath.Join(project, "missing")+string(os.PathListSeparator)+shared)
+	writeConfigFiles(t, home, map[string]string{".dox/base.yaml": "aliases:\n  home: ps\n"})
+	writeConfigFiles(t, shared, map[string]string{"team.yaml": "aliases:\n  team: logs\n"})
+	writeConfigFiles(t, project, map[string]string{"dox.yaml": "include: [~/.dox/base.yaml, team.yaml]\n"})
+
+	config, err := LoadConfig(filepath.Join(project, "dox.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"home": "ps", "team": "logs"}, config.Aliases)
+}
+
+func TestLoadConfig_IncludeInterpolation(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("DOX_TEST_TEAM", "shop")
+	writeConfigFiles(t, dir, map[string]string{
+		"teams/shop.yaml": "aliases:\n  team: logs\n",
+		"dox.yaml":        "include: [\"teams/${DOX_TEST_TEAM}.yaml\"]\n",
+	})
+
+	config, err := LoadConfig(filepath.Join(dir, "dox.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"team": "logs"}, config.Aliases)
+	assert.Equal(t, StringList{"teams/shop.yaml"}, config.Include)
+}
+
+func TestLoadConfig_IncludeNotFound(t *testing.T) {
+	dir := t.TempDir()
+	writeConfigFiles(t, dir, map[string]string{"dox.yaml": "version: 1\ninclude: missing.yaml\n"})
+
+	_, err := LoadConfig(filepath.Join(dir, "dox.yaml"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "dox.yaml: line 2: include 'missing.yaml' not found in "+dir)
+}
+
+func TestLoadConfig_IncludeCycle(t *testing.T) {
+	dir := t.TempDir()
+	writeConfigFiles(t, dir, map[string]string{
+		"dox.yaml": "include: a.yaml\n",
+		"a.yaml":   "include: b.yaml\n",
+		"b.yaml":   "include: a.yaml\n",
+	})
+
+	_, err := LoadConfig(filepath.Join(dir, "dox.yaml"))
+	require.Error(t, err)
+	a, b := filepath.Join(dir, "a.yaml"), filepath.Join(dir, "b.yaml")
+	assert.Contains(t, err.Error(), "include cycle: "+filepath.Join(dir, "dox.yaml")+" -> "+a+" -> "+b+" -> "+a)
+}
+
+func TestLoadConfig_IncludeErrorsPointAtIncludedFile(t *testing.T) {
+	dir := t.TempDir()
+	writeConfigFiles(t, dir, map[string]string{
+		"dox.yaml":      "include: [team.yaml]\n",
+		"team.yaml":     "aliases:\n  a: ps\n  b: ${DOX_TEST_UNSET:?needed}\n",
+		"bad.yaml":      "profiles:\n  dev:\n    slices: {not: a list}\n",
+		"uses-bad.yaml": "include: bad.yaml\n",
+	})
+
+	_, err := LoadConfig(filepath.Join(dir, "dox.yaml"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), filepath.Join(dir, "team.yaml")+": line 3, column 6: required variable 'DOX_TEST_UNSET' is not set")
+
+	_, err = LoadConfig(filepath.Join(dir, "uses-bad.yaml"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), filepath.Join(dir, "bad.yaml")+": failed to parse config file")
+	assert.Contains(t, err.Error(), "line 3")
+}
@@ -0,0 +1,267 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Built-in variables available to interpolation in dox.yaml
+const (
+	VarProfile   = "DOX_PROFILE"
+	VarGitBranch = "GIT_BRANCH"
+	VarUser      = "USER"
+)
+
+// interpolator expands ${VAR} references in the string values of dox.yaml.
+// Variables resolve from, in order: DOX_PROFILE, the vars block, the process
+// environment, then the GIT_BRANCH and USER built-ins. DOX_PROFILE is the
+// profile a value is defined in, and the selected profile elsewhere.
+type interpolator struct {
+	dir      string
+	sources  map[*yaml.Node]string // file each node came from, for errors
+	vars     map[string]string
+	selected string // profile the command runs with, if known
+	profile  string // profile whose values are being expanded, if any
+	warnings []string
+
+	gitBranch *string
+}
+
+// newInterpolator creates an interpolator for the dox.yaml in dir. selected
+// is the profile chosen on the command line, if any.
+func newInterpolator(dir string, sources map[*yaml.Node]string, selected string) *interpolator {
+	return &interpolator{dir: dir, sources: sources, vars: map[string]string{}, selected: selected}
+}
+
+var varName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*`)
+
+// interpolateConfig expands the values of the merged top-level mapping of
+// dox.yaml in place. The vars block is expanded first, each entry seeing the
+// ones before it. Without a selected profile, DOX_PROFILE is default_profile.
+// include entries are expanded by the loader before merging.
+func (in *interpolator) interpolateConfig(root *yaml.Node) error {
+	if in.selected == "" {
+		if err := in.selectDefault(root); err != nil {
+			return err
+		}
+	}
+
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value != "vars" || root.Content[i+1].Kind != yaml.MappingNode {
+			continue
+		}
+		vars := root.Content[i+1]
+		for j := 0; j+1 < len(vars.Content); j += 2 {
+			if err := in.expandNode(vars.Content[j+1]); err != nil {
+				return err
+			}
+			in.vars[vars.Content[j].Value] = vars.Content[j+1].Value
+		}
+	}
+
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, value := root.Content[i].Value, root.Content[i+1]
+		switch {
+		case key == "vars", key == "include":
+			continue
+		case key == "profiles" && value.Kind == yaml.MappingNode:
+			for j := 0; j+1 < len(value.Content); j += 2 {
+				in.profile = value.Content[j].Value
+				if err := in.walk(value.Content[j+1]); err != nil {
+					return err
+				}
+			}
+			in.profile = ""
+		default:
+			if err := in.walk(value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// selectDefault makes default_profile, or the legacy defaults.profile, the
+// selected profile
+func (in *interpolator) selectDefault(root *yaml.Node) error {
+	node := mappingValue(root, "default_profile")
+	if node == nil {
+		if defaults := mappingValue(root, "defaults"); defaults != nil {
+			node = mappingValue(defaults, "profile")
+		}
+	}
+	if node == nil || node.Kind != yaml.ScalarNode {
+		return nil
+	}
+	if err := in.expandNode(node); err != nil {
+		return err
+	}
+	in.selected = node.Value
+	return nil
+}
+
+// walk expands every string value under node; mapping keys are left as is
+func (in *interpolator) walk(node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		return in.expandNode(node)
+	case yaml.MappingNode:
+		for i := 1; i < len(node.Content); i += 2 {
+			if err := in.walk(node.Content[i]); err != nil {
+				return err
+			}
+		}
+	case yaml.SequenceNode:
+		for _, child := range node.Content {
+			if err := in.walk(child); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// expandNode expands a string scalar, reporting errors at its location
+func (in *interpolator) expandNode(node *yaml.Node) error {
+	if node.Kind != yaml.ScalarNode || node.ShortTag() != "!!str" {
+		return nil
+	}
+	value, unset, err := in.expand(node.Value)
+	if err != nil {
+		return &ConfigError{Path: in.sources[node], Err: fmt.Errorf("line %d, column %d: %w", node.Line, node.Column, err)}
+	}
+	for _, name := range unset {
+		in.warnings = append(in.warnings, fmt.Sprintf("%s: line %d, column %d: variable '%s' is not set, using an empty string",
+			filepath.Base(in.sources[node]), node.Line, node.Column, name))
+	}
+	node.Value = value
+	return nil
+}
+
+// expand replaces ${VAR}, ${VAR:-default}, ${VAR-default}, ${VAR:?error}
+// and ${VAR?error} in value. Defaults may contain references themselves,
+// like ${A:-${B}}. $${ is an escaped ${; other $ signs are kept as written.
+// It also returns the plain ${VAR} references that are unset.
+func (in *interpolator) expand(value string) (string, []string, error) {
+	var b strings.Builder
+	var unset []string
+	s := value
+	for {
+		i := strings.Index(s, "${")
+		if i < 0 {
+			b.WriteString(s)
+			return b.String(), unset, nil
+		}
+		if i > 0 && s[i-1] == '$' {
+			b.WriteString(s[:i-1] + "${")
+			s = s[i+2:]
+			continue
+		}
+		end := closingBrace(s[i+2:])
+		if end < 0 {
+			return "", nil, fmt.Errorf("unterminated variable reference in %q", value)
+		}
+		resolved, refUnset, err := in.resolve(s[i+2 : i+2+end])
+		if err != nil {
+			return "", nil, err
+		}
+		unset = append(unset, refUnset...)
+		b.WriteString(s[:i] + resolved)
+		s = s[i+2+end+1:]
+	}
+}
+
+// closingBrace returns the index of the } that ends a reference starting
+// at s, skipping the references nested in it, or -1 when there is none
+func closingBrace(s string) int {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch {
+		case strings.HasPrefix(s[i:], "${"):
+			depth++
+			i++
+		case s[i] == '}' && depth == 0:
+			return i
+		case s[i] == '}':
+			depth--
+		}
+	}
+	return -1
+}
+
+// resolve evaluates the inside of a ${...} reference, expanding the default
+// when it is used, and returns the unset variables it read
+func (in *interpolator) resolve(expr string) (string, []string, error) {
+	name := varName.FindString(expr)
+	if name == "" {
+		return "", nil, fmt.Errorf("invalid variable reference ${%s}", expr)
+	}
+	value, ok := in.lookup(name)
+	op := expr[len(name):]
+
+	switch {
+	case op == "":
+		if !ok {
+			return "", []string{name}, nil
+		}
+	case strings.HasPrefix(op, ":-"):
+		if !ok || value == "" {
+			return in.expand(op[2:])
+		}
+	case strings.HasPrefix(op, "-"):
+		if !ok {
+			return in.expand(op[1:])
+		}
+	case strings.HasPrefix(op, ":?"), strings.HasPrefix(op, "?"):
+		message := strings.TrimPrefix(strings.TrimPrefix(op, ":"), "?")
+		if !ok || (op[0] == ':' && value == "") {
+			if message == "" {
+				return "", nil, fmt.Errorf("required variable '%s' is not set", name)
+			}
+			return "", nil, fmt.Errorf("required variable '%s' is not set: %s", name, message)
+		}
+	default:
+		return "", nil, fmt.Errorf("invalid variable reference ${%s}", expr)
+	}
+	return value, nil, nil
+}
+
+// lookup returns the value of a variable and whether it is set
+func (in *interpolator) lookup(name string) (string, bool) {
+	if name == VarProfile && in.profile != "" {
+		return in.profile, true
+	}
+	if name == VarProfile && in.selected != "" {
+		return in.selected, true
+	}
+	if value, ok := in.vars[name]; ok {
+		return value, true
+	}
+	if value, ok := os.LookupEnv(name); ok {
+		return value, true
+	}
+
+	switch name {
+	case VarGitBranch:
+		if in.gitBranch == nil {
+			branch := ""
+			if out, err := exec.Command("git", "-C", in.dir, "rev-parse", "--abbrev-ref", "HEAD").Output(); err == nil {
+				branch = strings.TrimSpace(string(out))
+			}
+			in.gitBranch = &branch
+		}
+		return *in.gitBranch, *in.gitBranch != ""
+	case VarUser:
+		if u, err := user.Current(); err == nil {
+			return u.Username, true
+		}
+	}
+	return "", false
+}
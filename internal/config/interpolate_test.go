@@ -0,0 +1,167 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func loadConfigContent(t *testing.T, content string) (*Config, error) {
+	t.Helper()
+	configPath := filepath.Join(t.TempDir(), "dox.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte(content), 0644))
+	return LoadConfig(configPath)
+}
+
+func TestLoadConfig_Interpolation(t *testing.T) {
+	t.Setenv("DOX_TEST_REGION", "eu")
+	t.Setenv("DOX_TEST_EMPTY", "")
+	t.Setenv("USER", "alice")
+
+	config, err := loadConfigContent(t, `
+version: 1
+vars:
+  REGISTRY: ghcr.io/acme
+  IMAGE: ${REGISTRY}/api
+  DOX_TEST_REGION: us
+hooks:
+  pre_up: ["echo ${IMAGE} for ${USER}", "echo $HOME $${LITERAL}"]
+aliases:
+  region: "logs ${DOX_TEST_REGION}"
+profiles:
+  dev:
+    slices: ["${DOX_TEST_SLICE:-dev}", "db${DOX_TEST_EMPTY-x}"]
+    env_file: .env.${DOX_PROFILE}
+    environment:
+      TIER: ${DOX_TEST_EMPTY:-free}
+`)
+	require.NoError(t, err)
+
+	assert.Equal(t, "ghcr.io/acme/api", config.Vars["IMAGE"])
+	assert.Equal(t, []string{"echo ghcr.io/acme/api for alice", "echo $HOME ${LITERAL}"}, config.Hooks["pre_up"])
+	assert.Equal(t, "logs us", config.Aliases["region"], "vars take precedence over the process environment")
+
+	dev := config.Profiles["dev"]
+	assert.Equal(t, []string{"dev", "db"}, dev.Slices)
+	assert.Equal(t, ".env.dev", dev.EnvFile)
+	assert.Equal(t, "free", dev.Environment["TIER"])
+}
+
+func TestLoadConfig_InterpolationUnsetIsEmpty(t *testing.T) {
+	config, err := loadConfigContent(t, `
+profiles:
+  dev:
+    project_name: app${DOX_TEST_UNSET}
+    environment:
+      TIER: ${DOX_TEST_UNSET:-free}
+`)
+	require.NoError(t, err)
+	assert.Equal(t, "app", config.Profiles["dev"].ProjectName)
+	assert.Equal(t, []string{"dox.yaml: line 4, column 19: variable 'DOX_TEST_UNSET' is not set, using an empty string"}, config.Warnings,
+		"only references without a default warn")
+}
+
+func TestLoadConfig_InterpolationSelectedProfile(t *testing.T) {
+	content := `
+version: 2
+default_profile: dev
+hooks:
+  pre_up: ["echo deploying ${DOX_PROFILE}"]
+env_files:
+  shared: .env.${DOX_PROFILE}
+profiles:
+  dev:
+    env_file: .env.${DOX_PROFILE}
+  prod:
+    extends: [dev]
+`
+	config, err := loadConfigContent(t, content)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"echo deploying dev"}, config.Hooks["pre_up"], "default_profile outside profiles")
+	assert.Equal(t, ".env.dev", config.EnvFiles["shared"])
+	assert.Empty(t, config.Warnings)
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "dox.yaml"), []byte(content), 0644))
+	config, _, err = LoadConfigForProfile(dir, "prod")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"echo deploying prod"}, config.Hooks["pre_up"])
+	assert.Equal(t, ".env.dev", config.Profiles["dev"].EnvFile, "inside a profile, the profile the value is defined in")
+}
+
+func TestLoadConfig_InterpolationEscape(t *testing.T) {
+	t.Setenv("DOX_TEST_USER", "alice")
+
+	config, err := loadConfigContent(t, `
+profiles:
+  dev:
+    env_files: [".env.$${DOX_TEST_USER}"]
+`)
+	require.NoError(t, err)
+	path, _ := ParseEnvFileEntry(config.Profiles["dev"].EnvFiles[0])
+	assert.Equal(t, ".env.${DOX_TEST_USER}", path, "an escaped reference is not expanded again")
+}
+
+func TestLoadConfig_InterpolationNestedDefault(t *testing.T) {
+	t.Setenv("DOX_TEST_REGION", "eu")
+
+	config, err := loadConfigContent(t, `
+aliases:
+  region: "logs ${DOX_TEST_UNSET:-${DOX_TEST_REGION}}-1"
+  fallback: "logs ${DOX_TEST_UNSET:-${DOX_TEST_OTHER:-us}}"
+  unused: "logs ${DOX_TEST_REGION:-${DOX_TEST_UNSET}}"
+  missing: "logs ${DOX_TEST_UNSET-${DOX_TEST_OTHER}}"
+`)
+	require.NoError(t, err)
+	assert.Equal(t, "logs eu-1", config.Aliases["region"])
+	assert.Equal(t, "logs us", config.Aliases["fallback"])
+	assert.Equal(t, "logs eu", config.Aliases["unused"], "an unused default is not expanded")
+	assert.Equal(t, "logs ", config.Aliases["missing"])
+	assert.Equal(t, []string{"dox.yaml: line 6, column 12: variable 'DOX_TEST_OTHER' is not set, using an empty string"}, config.Warnings)
+}
+
+func TestLoadConfig_InterpolationRequired(t *testing.T) {
+	_, err := loadConfigContent(t, `
+version: 1
+profiles:
+  prod:
+    env_file: ${DOX_TEST_UNSET:?set the prod env file}
+`)
+	require.Error(t, err)
+
+	var configErr *ConfigError
+	require.True(t, errors.As(err, &configErr))
+	assert.Contains(t, err.Error(), "line 5, column 15: required variable 'DOX_TEST_UNSET' is not set: set the prod env file")
+}
+
+func TestLoadConfig_InterpolationErrors(t *testing.T) {
+	tests := []struct {
+		value string
+		err   string
+	}{
+		{"${DOX_TEST_UNSET?}", "required variable 'DOX_TEST_UNSET' is not set"},
+		{"${1BAD}", "invalid variable reference ${1BAD}"},
+		{"${NAME:x}", "invalid variable reference ${NAME:x}"},
+		{"${NAME", "unterminated variable reference"},
+		{"${NAME:-${OTHER}", "unterminated variable reference"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			_, err := loadConfigContent(t, "aliases:\n  a: \""+tt.value+"\"\n")
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "line 2, column 6: "+tt.err)
+		})
+	}
+}
+
+func TestLoadConfig_InterpolationGitBranch(t *testing.T) {
+	t.Setenv("GIT_BRANCH", "feature")
+
+	config, err := loadConfigContent(t, "profiles:\n  dev:\n    project_name: app-${GIT_BRANCH}\n")
+	require.NoError(t, err)
+	assert.Equal(t, "app-feature", config.Profiles["dev"].ProjectName)
+}
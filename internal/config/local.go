@@ -0,0 +1,69 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LocalFileNames are merged over dox.yaml, in order, when they exist next to
+// it. They hold a developer's own settings and are not meant to be committed.
+var LocalFileNames = []string{"dox.override.yaml", "dox.local.yaml"}
+
+// findLocalFiles returns the local override files that exist in dir
+func findLocalFiles(dir string) []string {
+	var files []string
+	for _, name := range LocalFileNames {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			files = append(files, path)
+		}
+	}
+	return files
+}
+
+// IgnoreLocalFiles adds the local override files to the .gitignore in dir,
+// creating it if needed, and returns the names it added
+func IgnoreLocalFiles(dir string) ([]string, error) {
+	path := filepath.Join(dir, ".gitignore")
+	ignored := map[string]bool{}
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	scanner := bufio.NewScanner(strings.NewReader(string(data)))
+	for scanner.Scan() {
+		ignored[strings.TrimPrefix(strings.TrimSpace(scanner.Text()), "/")] = true
+	}
+
+	var added []string
+	for _, name := range LocalFileNames {
+		if !ignored[name] {
+			added = append(added, name)
+		}
+	}
+	if len(added) == 0 {
+		return nil, nil
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var b strings.Builder
+	if len(data) > 0 && !strings.HasSuffix(string(data), "\n") {
+		b.WriteString("\n")
+	}
+	b.WriteString("# dox local overrides\n")
+	for _, name := range added {
+		fmt.Fprintln(&b, name)
+	}
+	if _, err := f.WriteString(b.String()); err != nil {
+		return nil, err
+	}
+	return added, nil
+}
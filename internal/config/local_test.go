@@ -0,0 +1,90 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadConfigFromDirectory_LocalOverrides(t *testing.T) {
+	dir := t.TempDir()
+	writeConfigFiles(t, dir, map[string]string{
+		"dox.yaml": `
+defaults:
+  profile: dev
+aliases:
+  l: logs
+profiles:
+  dev:
+    slices: [dev]
+    project_name: shop
+`,
+		"dox.override.yaml": "aliases:\n  l: logs -f\n  p: ps\n",
+		"dox.local.yaml": `
+defaults:
+  profile: mine
+aliases:
+  p: ps -a
+profiles:
+  mine:
+    extends: dev
+    slices: [debugger]
+`,
+	})
+
+	config, path, err := LoadConfigFromDirectory(dir)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "dox.yaml"), path)
+	assert.Equal(t, []string{filepath.Join(dir, "dox.override.yaml"), filepath.Join(dir, "dox.local.yaml")}, config.Overrides)
+	assert.Equal(t, "mine", config.GetDefaultProfile())
+	assert.Equal(t, map[string]string{"l": "logs -f", "p": "ps -a"}, config.Aliases)
+	assert.Equal(t, "shop", config.Profiles["dev"].ProjectName, "profiles from dox.yaml are kept")
+	assert.Equal(t, StringList{"dev"}, config.Profiles["mine"].Extends)
+
+	config, err = LoadConfig(filepath.Join(dir, "dox.yaml"))
+	require.NoError(t, err)
+	assert.Empty(t, config.Overrides, "LoadConfig reads only the given file")
+	assert.Equal(t, "dev", config.GetDefaultProfile())
+}
+
+func TestLoadConfigFromDirectory_LocalWithoutConfig(t *testing.T) {
+	dir := t.TempDir()
+	writeConfigFiles(t, dir, map[string]string{"dox.local.yaml": "aliases:\n  l: logs\n"})
+
+	config, _, err := LoadConfigFromDirectory(dir)
+	require.NoError(t, err)
+	assert.Nil(t, config)
+}
+
+func TestIgnoreLocalFiles(t *testing.T) {
+	dir := t.TempDir()
+	gitignore := filepath.Join(dir, ".gitignore")
+	require.NoError(t, os.WriteFile(gitignore, []byte("node_modules\n/dox.override.yaml"), 0644))
+
+	added, err := IgnoreLocalFiles(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"dox.local.yaml"}, added)
+
+	data, err := os.ReadFile(gitignore)
+	require.NoError(t, err)
+	assert.Equal(t, "node_modules\n/dox.override.yaml\n# dox local overrides\ndox.local.yaml\n", string(data))
+
+	added, err = IgnoreLocalFiles(dir)
+	require.NoError(t, err)
+	assert.Empty(t, added)
+}
+
+func TestIgnoreLocalFiles_CreatesGitignore(t *testing.T) {
+	dir := t.TempDir()
+
+	added, err := IgnoreLocalFiles(dir)
+	require.NoError(t, err)
+	assert.Equal(t, LocalFileNames, added)
+
+	data, err := os.ReadFile(filepath.Join(dir, ".gitignore"))
+	require.NoError(t, err)
+	assert.Equal(t, "# dox local overrides\ndox.override.yaml\ndox.local.yaml\n", string(data))
+}
@@ -4,25 +4,55 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-
-	"gopkg.in/yaml.v3"
 )
 
-// LoadConfig loads a dox.yaml configuration file
+// LoadConfig loads a dox.yaml configuration file merged over the files it
+// includes
 func LoadConfig(path string) (*Config, error) {
-	data, err := os.ReadFile(path)
+	return loadConfig(path, nil, "")
+}
+
+// loadConfig loads path and merges each override file over it. ${DOX_PROFILE}
+// outside profiles resolves to selected, or default_profile when empty.
+func loadConfig(path string, overrides []string, selected string) (*Config, error) {
+	loader := newLoader(filepath.Dir(path), selected)
+	root, err := loader.load(path, nil)
 	if err != nil {
-	 return nil, fmt.Errorf("failed to read config file: %w", err)
+	 return nil, err
+	}
+	for _, override := range overrides {
+	 node, err := loader.load(override, nil)
+	 if err != nil {
+   return nil, err
+	 }
+	 root = mergeNodes(root, node)
+	}
+
+	// Expand ${VAR} references before decoding so errors carry locations
+	if err := loader.in.interpolateConfig(root); err != nil {
+	 return nil, err
 	}
 
 	var config Config
-	if err := yaml.Unmarshal(data, &config); err != nil {
-	 return nil, fmt.Errorf("failed to parse config file: %w", err)
+	if err := root.Decode(&config); err != nil {
+	 return nil, &ConfigError{Path: path, Err: fmt.Errorf("failed to parse config file: %w", err)}
 	}
 
 	// Validate version
-	if config.Version != 1 && config.Version != 0 {
-	 return nil, fmt.Errorf("unsupported config version: %d", config.Version)
+	if config.Version < 0 || config.Version > CurrentVersion {
+	 return nil, configErrorf(path, "unsupported config version: %d", config.Version)
+	}
+
+	config.Path = path
+	config.Files = loader.files
+	config.Overrides = overrides
+	config.Warnings = loader.in.warnings
+
+	// Version 2 keys still apply in older files, but ask for a migration
+	if config.Version < 2 {
+	 for _, key := range config.version2Keys() {
+   config.Warnings = append(config.Warnings, fmt.Sprintf("%s: %s requires version: 2; run 'dox config migrate'", filepath.Base(path), key))
+	 }
 	}
 
 	return &config, nil
@@ -37,15 +67,22 @@ func FindConfigFile(dir string) (string, error) {
 	return "", fmt.Errorf("no dox.yaml found in %s", dir)
 }
 
-// LoadConfigFromDirectory loads dox.yaml from directory if it exists
+// LoadConfigFromDirectory loads dox.yaml from directory if it exists, with
+// the local override files merged over it
 func LoadConfigFromDirectory(dir string) (*Config, string, error) {
+	return LoadConfigForProfile(dir, "")
+}
+
+// LoadConfigForProfile is LoadConfigFromDirectory for a command that runs
+// with the given profile, which ${DOX_PROFILE} then resolves to
+func LoadConfigForProfile(dir, profile string) (*Config, string, error) {
 	configPath, err := FindConfigFile(dir)
 	if err != nil {
 	 // No config file is not an error
 	 return nil, "", nil
 	}
 
-	config, err := LoadConfig(configPath)
+	config, err := loadConfig(configPath, findLocalFiles(dir), profile)
 	if err != nil {
 	 return nil, "", err
 	}
@@ -202,3 +202,40 @@ func TestLoadConfigFromDirectory_NoConfig(t *testing.T) {
 	assert.Empty(t, configPath)
 	assert.Nil(t, config)
 }
+
+func TestLoadConfig_ConfigError(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "dox.yaml")
+	err := os.WriteFile(configPath, []byte("version: 9\n"), 0644)
+	require.NoError(t, err)
+
+	_, err = LoadConfig(configPath)
+	var configErr *ConfigError
+	require.ErrorAs(t, err, &configErr)
+	assert.Equal(t, configPath, configErr.Path)
+	assert.Contains(t, err.Error(), "unsupported config version: 9")
+}
+
+func TestLoadConfig_ExtendsStringOrList(t *testing.T) {
+	content := `
+version: 1
+profiles:
+  base:
+    slices: [db]
+  prod:
+    extends: base
+  full:
+    extends: [base, prod]
+    slices: ["!db"]
+`
+	configPath := filepath.Join(t.TempDir(), "dox.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte(content), 0644))
+
+	config, err := LoadConfig(configPath)
+	require.NoError(t, err)
+
+	assert.Empty(t, config.Profiles["base"].Extends)
+	assert.Equal(t, StringList{"base"}, config.Profiles["prod"].Extends)
+	assert.Equal(t, StringList{"base", "prod"}, config.Profiles["full"].Extends)
+	assert.Equal(t, []string{"!db"}, config.Profiles["full"].Slices)
+}
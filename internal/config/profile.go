@@ -0,0 +1,273 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Profile fields that are inherited through extends and can be named in reset
+const (
+	FieldSlices      = "slices"
+	FieldEnvFile     = "env_file"
+	FieldEnvFiles    = "env_files"
+	FieldEnvironment = "environment"
+	FieldHooks       = "hooks"
+	FieldAliases     = "aliases"
+	FieldProjectName = "project_name"
+	FieldContext     = "context"
+)
+
+// ResetFields lists the profile fields accepted by reset
+var ResetFields = []string{FieldSlices, FieldEnvFile, FieldEnvFiles, FieldEnvironment, FieldHooks, FieldAliases, FieldProjectName, FieldContext}
+
+// topLevel stands for the top level of dox.yaml among the sources of a value
+const topLevel = ""
+
+// EffectiveProfile holds the settings of a profile after inheritance.
+//
+// Lists and maps are combined along the extends linearization (see
+// TraceProfile): env_files are concatenated, hooks are concatenated after
+// the top-level hooks, and environment and aliases are merged with later
+// profiles winning. Scalars are overridden: a profile that does not set one
+// inherits it from its parents, which must not disagree. A profile that
+// lists a field in reset inherits nothing for it, including the top-level
+// hooks and aliases.
+type EffectiveProfile struct {
+	Name        string
+	Order       []string
+	EnvFile     string
+	EnvFiles    []string // entries as written, see ParseEnvFileEntry
+	ProjectName string
+	Context     string
+	Environment map[string]string
+	Hooks       map[string][]string
+	Aliases     map[string]string
+	// Sources maps each value, such as "env_file", "environment.KEY" or
+	// "hooks.pre_up[0]", to the profile that set it, or "" for the top level
+	Sources map[string]string
+}
+
+// EffectiveProfile resolves every field of a profile through extends
+func (c *Config) EffectiveProfile(profileName string) (*EffectiveProfile, error) {
+	if _, exists := c.Profiles[profileName]; !exists {
+		return nil, configErrorf(c.Path, "profile '%s' not found", profileName)
+	}
+
+	p := &EffectiveProfile{
+		Name:        profileName,
+		Environment: map[string]string{},
+		Hooks:       map[string][]string{},
+		Aliases:     map[string]string{},
+		Sources:     map[string]string{},
+	}
+	if err := c.linearize(profileName, nil, map[string]bool{}, &p.Order); err != nil {
+		return nil, err
+	}
+	dropped, err := c.resetDropped(p.Order)
+	if err != nil {
+		return nil, err
+	}
+
+	addHooks := func(hooks map[string][]string, source string) {
+		for hookType, commands := range hooks {
+			for _, command := range commands {
+				p.Sources[fmt.Sprintf("hooks.%s[%d]", hookType, len(p.Hooks[hookType]))] = source
+				p.Hooks[hookType] = append(p.Hooks[hookType], command)
+			}
+		}
+	}
+	addAliases := func(aliases map[string]string, source string) {
+		for name, def := range aliases {
+			p.Aliases[name] = def
+			p.Sources["aliases."+name] = source
+		}
+	}
+
+	if !dropped[FieldHooks][topLevel] {
+		addHooks(c.Hooks, topLevel)
+	}
+	if !dropped[FieldAliases][topLevel] {
+		addAliases(c.Aliases, topLevel)
+	}
+	for _, name := range p.Order {
+		profile := c.Profiles[name]
+		if !dropped[FieldEnvFiles][name] {
+			for _, entry := range profile.EnvFiles {
+				p.Sources[fmt.Sprintf("env_files[%d]", len(p.EnvFiles))] = name
+				p.EnvFiles = append(p.EnvFiles, entry)
+			}
+		}
+		if !dropped[FieldEnvironment][name] {
+			for key, value := range profile.Environment {
+				p.Environment[key] = value
+				p.Sources["environment."+key] = name
+			}
+		}
+		if !dropped[FieldHooks][name] {
+			addHooks(profile.Hooks, name)
+		}
+		if !dropped[FieldAliases][name] {
+			addAliases(profile.Aliases, name)
+		}
+	}
+
+	scalars := []struct {
+		field string
+		value *string
+		own   func(Profile) (string, bool)
+	}{
+		{FieldEnvFile, &p.EnvFile, c.profileEnvFile},
+		{FieldProjectName, &p.ProjectName, func(p Profile) (string, bool) { return p.ProjectName, p.ProjectName != "" }},
+		{FieldContext, &p.Context, func(p Profile) (string, bool) { return p.Context, p.Context != "" }},
+	}
+	for _, s := range scalars {
+		value, owner, err := c.inheritScalar(profileName, s.field, s.own)
+		if err != nil {
+			return nil, err
+		}
+		*s.value = value
+		if value != "" {
+			p.Sources[s.field] = owner
+		}
+	}
+
+	return p, nil
+}
+
+// ParseEnvFileEntry returns the path of an env_files entry and whether it is
+// optional, written with a leading "?". Variables were already expanded when
+// dox.yaml was loaded.
+func ParseEnvFileEntry(entry string) (path string, optional bool) {
+	return strings.CutPrefix(entry, "?")
+}
+
+// linearize appends the profiles name extends, then name itself, to order.
+// path holds the profiles being visited, to report the full cycle.
+func (c *Config) linearize(name string, path []string, done map[string]bool, order *[]string) error {
+	if done[name] {
+		return nil
+	}
+	if i := slices.Index(path, name); i >= 0 {
+		cycle := append(slices.Clone(path[i:]), name)
+		return configErrorf(c.Path, "circular profile inheritance: %s", strings.Join(cycle, " -> "))
+	}
+
+	path = append(slices.Clone(path), name)
+	for _, parent := range c.Profiles[name].Extends {
+		if _, exists := c.Profiles[parent]; !exists {
+			return configErrorf(c.Path, "profile '%s' extends non-existent profile '%s'", name, parent)
+		}
+		if err := c.linearize(parent, path, done, order); err != nil {
+			return err
+		}
+	}
+
+	done[name] = true
+	*order = append(*order, name)
+	return nil
+}
+
+// resetDropped returns, for each field, the profiles in order whose values
+// are not inherited because a profile resets the field. The top level of
+// dox.yaml is dropped by any reset of the field.
+func (c *Config) resetDropped(order []string) (map[string]map[string]bool, error) {
+	dropped := map[string]map[string]bool{}
+	for _, name := range order {
+		reset := c.Profiles[name].Reset
+		if len(reset) == 0 {
+			continue
+		}
+
+		var ancestors []string
+		if err := c.linearize(name, nil, map[string]bool{}, &ancestors); err != nil {
+			return nil, err
+		}
+		ancestors = append(ancestors[:len(ancestors)-1], topLevel)
+
+		for _, field := range reset {
+			if !slices.Contains(ResetFields, field) {
+				return nil, configErrorf(c.Path, "profile '%s' resets unknown field '%s' (expected one of: %s)", name, field, strings.Join(ResetFields, ", "))
+			}
+			if dropped[field] == nil {
+				dropped[field] = map[string]bool{}
+			}
+			for _, ancestor := range ancestors {
+				dropped[field][ancestor] = true
+			}
+		}
+	}
+	return dropped, nil
+}
+
+// inheritScalar returns a scalar field of a profile and the profile that set
+// it. own reports whether a profile sets the field itself. A profile that
+// does not inherits from its parents, which must not disagree, unless it
+// resets the field.
+func (c *Config) inheritScalar(name, field string, own func(Profile) (string, bool)) (value, owner string, err error) {
+	profile := c.Profiles[name]
+	if v, ok := own(profile); ok {
+		return v, name, nil
+	}
+	if slices.Contains(profile.Reset, field) {
+		return "", "", nil
+	}
+
+	for _, parent := range profile.Extends {
+		parentValue, parentOwner, err := c.inheritScalar(parent, field, own)
+		if err != nil {
+			return "", "", err
+		}
+		if parentValue == "" {
+			continue
+		}
+		if value != "" && parentValue != value {
+			return "", "", configErrorf(c.Path, "profile '%s' inherits conflicting %s '%s' (from '%s') and '%s' (from '%s'); set %s on '%s'",
+				name, fieldPlural(field), value, owner, parentValue, parentOwner, field, name)
+		}
+		if value == "" {
+			value, owner = parentValue, parentOwner
+		}
+	}
+	return value, owner, nil
+}
+
+// profileEnvFile returns the env file a profile sets itself. env_file takes
+// precedence over env.
+func (c *Config) profileEnvFile(profile Profile) (string, bool) {
+	if profile.EnvFile != "" {
+		return profile.EnvFile, true
+	}
+	if profile.Env != "" {
+		return c.EnvFiles[profile.Env], true
+	}
+	return "", false
+}
+
+// envFileSource describes how a profile sets its env file
+func (c *Config) envFileSource(name string) string {
+	profile := c.Profiles[name]
+	if profile.EnvFile != "" {
+		source := fmt.Sprintf("env_file of profile '%s'", name)
+		if profile.Env != "" {
+			source += fmt.Sprintf(", overriding env '%s'", profile.Env)
+		}
+		return source
+	}
+	if _, ok := c.EnvFiles[profile.Env]; ok {
+		return fmt.Sprintf("env '%s' of profile '%s', via env_files", profile.Env, name)
+	}
+	return fmt.Sprintf("env '%s' of profile '%s' is not defined in env_files", profile.Env, name)
+}
+
+func fieldPlural(field string) string {
+	switch field {
+	case FieldEnvFile:
+		return "env files"
+	case FieldProjectName:
+		return "project names"
+	case FieldContext:
+		return "contexts"
+	}
+	return field
+}
@@ -0,0 +1,125 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func inheritanceConfig() *Config {
+	return &Config{
+		Path:    "dox.yaml",
+		Hooks:   map[string][]string{"pre_up": {"echo top"}},
+		Aliases: map[string]string{"fresh": "down -v && up -d"},
+		Profiles: map[string]Profile{
+			"base": {
+				Slices:      []string{"db"},
+				ProjectName: "shop",
+				Environment: map[string]string{"LOG_LEVEL": "debug", "REGION": "eu"},
+				Hooks:       map[string][]string{"pre_up": {"echo base"}},
+			},
+			"prod": {
+				Extends:     StringList{"base"},
+				Slices:      []string{"prod"},
+				EnvFile:     ".env.prod",
+				Context:     "prod-ctx",
+				Environment: map[string]string{"LOG_LEVEL": "info"},
+				Aliases:     map[string]string{"deploy": "up -d"},
+			},
+			"monitoring": {
+				Extends: StringList{"base"},
+				Slices:  []string{"grafana"},
+				Hooks:   map[string][]string{"pre_up": {"echo grafana"}},
+			},
+			"prod-monitoring": {
+				Extends: StringList{"prod", "monitoring"},
+			},
+			"clean": {
+				Extends: StringList{"prod"},
+				Slices:  []string{"clean"},
+				Reset:   StringList{FieldSlices, FieldHooks, FieldEnvFile, FieldEnvironment},
+				Hooks:   map[string][]string{"pre_up": {"echo clean"}},
+			},
+		},
+	}
+}
+
+func TestEffectiveProfile_Inheritance(t *testing.T) {
+	p, err := inheritanceConfig().EffectiveProfile("prod-monitoring")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"base", "prod", "monitoring", "prod-monitoring"}, p.Order)
+	assert.Equal(t, ".env.prod", p.EnvFile)
+	assert.Equal(t, "shop", p.ProjectName)
+	assert.Equal(t, "prod-ctx", p.Context)
+	assert.Equal(t, map[string]string{"LOG_LEVEL": "info", "REGION": "eu"}, p.Environment)
+	assert.Equal(t, []string{"echo top", "echo base", "echo grafana"}, p.Hooks["pre_up"])
+	assert.Equal(t, map[string]string{"fresh": "down -v && up -d", "deploy": "up -d"}, p.Aliases)
+
+	assert.Equal(t, "prod", p.Sources["env_file"])
+	assert.Equal(t, "base", p.Sources["project_name"])
+	assert.Equal(t, "prod", p.Sources["environment.LOG_LEVEL"])
+	assert.Equal(t, "base", p.Sources["environment.REGION"])
+	assert.Equal(t, "", p.Sources["hooks.pre_up[0]"])
+	assert.Equal(t, "monitoring", p.Sources["hooks.pre_up[2]"])
+	assert.Equal(t, "", p.Sources["aliases.fresh"])
+}
+
+func TestEffectiveProfile_Reset(t *testing.T) {
+	cfg := inheritanceConfig()
+	p, err := cfg.EffectiveProfile("clean")
+	require.NoError(t, err)
+
+	assert.Equal(t, "", p.EnvFile)
+	assert.Empty(t, p.Environment)
+	assert.Equal(t, []string{"echo clean"}, p.Hooks["pre_up"])
+	assert.Equal(t, "shop", p.ProjectName)
+	assert.Equal(t, "prod-ctx", p.Context)
+	assert.Contains(t, p.Aliases, "deploy")
+
+	files, envFile, err := cfg.ResolveProfile("clean", &Discovery{Slices: map[string]string{"clean": "compose.clean.yaml"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"compose.clean.yaml"}, files)
+	assert.Equal(t, "", envFile)
+}
+
+func TestEffectiveProfile_Errors(t *testing.T) {
+	cfg := &Config{
+		Profiles: map[string]Profile{
+			"a":        {ProjectName: "one"},
+			"b":        {ProjectName: "two"},
+			"both":     {Extends: StringList{"a", "b"}},
+			"override": {Extends: StringList{"a", "b"}, ProjectName: "three"},
+			"bad":      {Reset: StringList{"slice"}},
+		},
+	}
+
+	_, err := cfg.EffectiveProfile("both")
+	assert.ErrorContains(t, err, "profile 'both' inherits conflicting project names 'one' (from 'a') and 'two' (from 'b'); set project_name on 'both'")
+
+	p, err := cfg.EffectiveProfile("override")
+	require.NoError(t, err)
+	assert.Equal(t, "three", p.ProjectName)
+
+	_, err = cfg.EffectiveProfile("bad")
+	assert.ErrorContains(t, err, "profile 'bad' resets unknown field 'slice'")
+
+	_, err = cfg.EffectiveProfile("missing")
+	assert.ErrorContains(t, err, "profile 'missing' not found")
+}
+
+func TestParseEnvFileEntry(t *testing.T) {
+	t.Setenv("DOX_TEST_USER", "alice")
+
+	path, optional := ParseEnvFileEntry(".env")
+	assert.Equal(t, ".env", path)
+	assert.False(t, optional)
+
+	path, optional = ParseEnvFileEntry("?.env.alice")
+	assert.Equal(t, ".env.alice", path)
+	assert.True(t, optional)
+
+	path, _ = ParseEnvFileEntry(".env.${DOX_TEST_USER}")
+	assert.Equal(t, ".env.${DOX_TEST_USER}", path, "entries are expanded once, when dox.yaml is loaded")
+}
@@ -0,0 +1,252 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// SliceTrace records where a slice of a resolved profile came from
+type SliceTrace struct {
+	Name      string
+	Profile   string // profile that lists the slice
+	File      string
+	Files     []string // files of a slice listed under slices, File is the first
+	Duplicate bool     // already included earlier, skipped
+	Excluded  bool     // a "!name" entry dropping the slice
+	DroppedBy string   // profile whose "!name" entry dropped this slice
+	Shadowed  string   // .yml twin ignored in favour of File
+}
+
+// ProfileTrace records each decision made while resolving a profile:
+// the extends graph, the origin of every slice, the base file and the
+// env file. Files and EnvFile are what ResolveProfile returns.
+type ProfileTrace struct {
+	Profile       string
+	Order         []string            // linearized extends graph, the profile last
+	Extends       map[string][]string // direct parents of each profile in Order
+	BaseFile      string
+	ShadowedBases []string // other base files present but not used
+	Slices        []SliceTrace
+	EnvFile       string
+	EnvFileSource string
+	Files         []string
+}
+
+// TraceProfile resolves a profile like ResolveProfile and records why each
+// compose file was chosen.
+//
+// Extended profiles are linearized depth-first, left to right, with every
+// profile placed after its parents and listed once; extends: [prod, monitoring]
+// gives prod's ancestors, prod, monitoring's remaining ancestors, monitoring,
+// then the profile itself. Slices are collected in that order, skipping
+// duplicates, and a "!name" entry drops name if it was collected before.
+// A profile that resets slices drops the slices of its ancestors.
+func (c *Config) TraceProfile(profileName string, discovery *Discovery) (*ProfileTrace, error) {
+	if _, exists := c.Profiles[profileName]; !exists {
+		return nil, configErrorf(c.Path, "profile '%s' not found", profileName)
+	}
+
+	trace := &ProfileTrace{Profile: profileName, Extends: map[string][]string{}}
+	if err := c.linearize(profileName, nil, map[string]bool{}, &trace.Order); err != nil {
+		return nil, err
+	}
+
+	dropped, err := c.resetDropped(trace.Order)
+	if err != nil {
+		return nil, err
+	}
+
+	// Collect slices in linearized order
+	active := map[string]int{} // slice name -> index in trace.Slices
+	for _, name := range trace.Order {
+		profile := c.Profiles[name]
+		if len(profile.Extends) > 0 {
+			trace.Extends[name] = profile.Extends
+		}
+		if dropped[FieldSlices][name] {
+			continue
+		}
+		for _, entry := range profile.Slices {
+			if excluded, ok := strings.CutPrefix(entry, "!"); ok {
+				if i, ok := active[excluded]; ok {
+					trace.Slices[i].DroppedBy = name
+					delete(active, excluded)
+				}
+				trace.Slices = append(trace.Slices, SliceTrace{Name: excluded, Profile: name, Excluded: true})
+				continue
+			}
+			if _, ok := active[entry]; ok {
+				trace.Slices = append(trace.Slices, SliceTrace{Name: entry, Profile: name, Duplicate: true})
+				continue
+			}
+			active[entry] = len(trace.Slices)
+			trace.Slices = append(trace.Slices, SliceTrace{Name: entry, Profile: name})
+		}
+	}
+
+	if discovery.BaseFile != "" {
+		trace.BaseFile = discovery.BaseFile
+		trace.ShadowedBases = ShadowedBaseFiles(discovery.BaseFile)
+		trace.Files = append(trace.Files, discovery.BaseFile)
+	}
+
+	// Resolve the remaining slices to files
+	for i := range trace.Slices {
+		slice := &trace.Slices[i]
+		if slice.Duplicate || slice.Excluded || slice.DroppedBy != "" {
+			continue
+		}
+
+		if _, ok := c.Slices[slice.Name]; ok {
+			files, err := c.SliceFiles(slice.Name)
+			var missing *MissingFileError
+			if errors.As(err, &missing) {
+				missing.Profile = slice.Profile
+				missing.Message = fmt.Sprintf("%s for profile '%s'", missing.Message, slice.Profile)
+			}
+			if err != nil {
+				return nil, err
+			}
+			slice.Files = files
+			slice.File = files[0]
+			trace.Files = appendNew(trace.Files, files...)
+			continue
+		}
+
+		sliceFile, exists := discovery.Slices[slice.Name]
+		if !exists {
+			return nil, &MissingFileError{
+				Path:    fmt.Sprintf("compose.%s.yaml", slice.Name),
+				Slice:   slice.Name,
+				Profile: slice.Profile,
+				Message: fmt.Sprintf("slice file 'compose.%s.yaml' not found for profile '%s'", slice.Name, slice.Profile),
+			}
+		}
+		slice.File = sliceFile
+		if strings.HasSuffix(sliceFile, ".yaml") {
+			twin := strings.TrimSuffix(sliceFile, ".yaml") + ".yml"
+			if _, err := os.Stat(twin); err == nil {
+				slice.Shadowed = twin
+			}
+		}
+		trace.Files = appendNew(trace.Files, sliceFile)
+	}
+
+	envFile, owner, err := c.inheritScalar(profileName, FieldEnvFile, c.profileEnvFile)
+	if err != nil {
+		return nil, err
+	}
+	trace.EnvFile = envFile
+	if owner != "" {
+		trace.EnvFileSource = c.envFileSource(owner)
+		if owner != profileName {
+			trace.EnvFileSource += fmt.Sprintf(", inherited by '%s'", profileName)
+		}
+	}
+
+	return trace, nil
+}
+
+// ShadowedBaseFiles returns the other base files next to base that lost to it
+func ShadowedBaseFiles(base string) []string {
+	dir := filepath.Dir(base)
+	var shadowed []string
+	for _, name := range BaseFileNames {
+		path := filepath.Join(dir, name)
+		if path == base {
+			continue
+		}
+		if _, err := os.Stat(path); err == nil {
+			shadowed = append(shadowed, path)
+		}
+	}
+	return shadowed
+}
+
+// SliceFiles resolves the files a slice lists under slices, relative to the
+// directory of dox.yaml. An entry is a file, a glob or a directory, which
+// stands for the compose files directly in it, and must match at least one
+// file. A file matched twice is listed once.
+func (c *Config) SliceFiles(name string) ([]string, error) {
+	entries, ok := c.Slices[name]
+	if !ok {
+		return nil, configErrorf(c.Path, "slice '%s' is not listed under slices", name)
+	}
+	if len(entries) == 0 {
+		return nil, configErrorf(c.Path, "slice '%s' lists no files", name)
+	}
+
+	dir := filepath.Dir(c.Path)
+	var files []string
+	for _, entry := range entries {
+		path := entry
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+
+		var matches []string
+		kind := "file"
+		if strings.ContainsAny(entry, "*?[") {
+			kind = "pattern"
+			found, err := filepath.Glob(path)
+			if err != nil {
+				return nil, configErrorf(c.Path, "slice '%s': invalid pattern '%s': %v", name, entry, err)
+			}
+			for _, match := range found {
+				if info, err := os.Stat(match); err == nil && !info.IsDir() {
+					matches = append(matches, match)
+				}
+			}
+		} else if info, err := os.Stat(path); err == nil && info.IsDir() {
+			kind = "directory"
+			matches = composeFilesIn(path)
+		} else if err == nil {
+			matches = []string{path}
+		}
+
+		if len(matches) == 0 {
+			var message string
+			switch kind {
+			case "pattern":
+				message = fmt.Sprintf("pattern '%s' of slice '%s' matched no files", entry, name)
+			case "directory":
+				message = fmt.Sprintf("directory '%s' of slice '%s' has no compose files", entry, name)
+			default:
+				message = fmt.Sprintf("file '%s' of slice '%s' not found", entry, name)
+			}
+			return nil, &MissingFileError{Path: entry, Slice: name, Message: message}
+		}
+		files = appendNew(files, matches...)
+	}
+	return files, nil
+}
+
+// composeFilesIn returns the .yaml and .yml files directly in dir, sorted
+func composeFilesIn(dir string) []string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var files []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() && (strings.HasSuffix(name, ".yaml") || strings.HasSuffix(name, ".yml")) {
+			files = append(files, filepath.Join(dir, name))
+		}
+	}
+	return files
+}
+
+// appendNew appends the files not already in list
+func appendNew(list []string, files ...string) []string {
+	for _, file := range files {
+		if !slices.Contains(list, file) {
+			list = append(list, file)
+		}
+	}
+	return list
+}
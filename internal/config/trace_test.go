@@ -0,0 +1,125 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTraceProfile(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"compose.yaml", "docker-compose.yml", "compose.db.yaml", "compose.db.yml", "compose.dev.yaml", "compose.debug.yaml"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte("services: {}\n"), 0644))
+	}
+	discovery, err := DiscoverFiles(dir)
+	require.NoError(t, err)
+
+	cfg := &Config{
+		EnvFiles: map[string]string{"local": ".env.local"},
+		Profiles: map[string]Profile{
+			"base":  {Slices: []string{"db"}},
+			"dev":   {Slices: []string{"dev", "db"}, Extends: StringList{"base"}, Env: "local"},
+			"debug": {Slices: []string{"debug"}, Extends: StringList{"dev"}, EnvFile: ".env.debug", Env: "local"},
+		},
+	}
+
+	trace, err := cfg.TraceProfile("debug", discovery)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"base", "dev", "debug"}, trace.Order)
+	assert.Equal(t, map[string][]string{"dev": {"base"}, "debug": {"dev"}}, trace.Extends)
+	assert.Equal(t, filepath.Join(dir, "compose.yaml"), trace.BaseFile)
+	assert.Equal(t, []string{filepath.Join(dir, "docker-compose.yml")}, trace.ShadowedBases)
+
+	require.Len(t, trace.Slices, 4)
+	assert.Equal(t, SliceTrace{Name: "db", Profile: "base", File: filepath.Join(dir, "compose.db.yaml"), Shadowed: filepath.Join(dir, "compose.db.yml")}, trace.Slices[0])
+	assert.Equal(t, SliceTrace{Name: "dev", Profile: "dev", File: filepath.Join(dir, "compose.dev.yaml")}, trace.Slices[1])
+	assert.Equal(t, SliceTrace{Name: "db", Profile: "dev", Duplicate: true}, trace.Slices[2])
+	assert.Equal(t, "debug", trace.Slices[3].Profile)
+
+	assert.Equal(t, ".env.debug", trace.EnvFile)
+	assert.Equal(t, "env_file of profile 'debug', overriding env 'local'", trace.EnvFileSource)
+
+	// ResolveProfile returns the traced files
+	files, envFile, err := cfg.ResolveProfile("debug", discovery)
+	require.NoError(t, err)
+	assert.Equal(t, trace.Files, files)
+	assert.Equal(t, trace.EnvFile, envFile)
+
+	trace, err = cfg.TraceProfile("dev", discovery)
+	require.NoError(t, err)
+	assert.Equal(t, ".env.local", trace.EnvFile)
+	assert.Equal(t, "env 'local' of profile 'dev', via env_files", trace.EnvFileSource)
+}
+
+func TestTraceProfile_ListedSlices(t *testing.T) {
+	dir := t.TempDir()
+	writeConfigFiles(t, dir, map[string]string{
+		"compose.yaml":        "services: {}\n",
+		"compose.dev.yaml":    "services: {}\n",
+		"ops/monitoring.yaml": "services: {}\n",
+		"ops/logging.yaml":    "services: {}\n",
+		"ops/README.md":       "ops\n",
+		"db/postgres.yaml":    "services: {}\n",
+		"db/redis.yml":        "services: {}\n",
+	})
+	discovery, err := DiscoverFiles(dir)
+	require.NoError(t, err)
+
+	cfg := &Config{
+		Path: filepath.Join(dir, "dox.yaml"),
+		Slices: map[string]StringList{
+			"dev":  {"ops/monitoring.yaml"},
+			"ops":  {"ops/monitoring.yaml", "ops/logging.yaml"},
+			"bad":  {"ops/missing.yaml"},
+			"all":  {"compose.yaml", "ops/*.yaml", "db", "ops/monitoring.yaml"},
+			"glob": {"nope/*.yaml"},
+		},
+		Profiles: map[string]Profile{
+			"dev":    {Slices: []string{"dev"}},
+			"ops":    {Slices: []string{"ops"}},
+			"broken": {Slices: []string{"bad"}},
+			"all":    {Slices: []string{"ops", "all"}},
+			"glob":   {Slices: []string{"glob"}},
+			"child":  {Extends: StringList{"broken"}},
+		},
+	}
+
+	trace, err := cfg.TraceProfile("ops", discovery)
+	require.NoError(t, err)
+	monitoring, logging := filepath.Join(dir, "ops/monitoring.yaml"), filepath.Join(dir, "ops/logging.yaml")
+	assert.Equal(t, []string{monitoring, logging}, trace.Slices[0].Files)
+	assert.Equal(t, []string{filepath.Join(dir, "compose.yaml"), monitoring, logging}, trace.Files)
+
+	trace, err = cfg.TraceProfile("dev", discovery)
+	require.NoError(t, err)
+	assert.Equal(t, monitoring, trace.Slices[0].File, "slices takes precedence over discovery")
+
+	_, err = cfg.TraceProfile("broken", discovery)
+	var missing *MissingFileError
+	require.ErrorAs(t, err, &missing)
+	assert.Equal(t, "bad", missing.Slice)
+	assert.Equal(t, "broken", missing.Profile)
+	assert.Equal(t, "file 'ops/missing.yaml' of slice 'bad' not found for profile 'broken'", missing.Message)
+
+	// The error names the profile that lists the slice, not the one selected
+	_, err = cfg.TraceProfile("child", discovery)
+	require.ErrorAs(t, err, &missing)
+	assert.Equal(t, "broken", missing.Profile)
+	assert.Equal(t, "file 'ops/missing.yaml' of slice 'bad' not found for profile 'broken'", missing.Message)
+
+	_, err = cfg.TraceProfile("glob", discovery)
+	require.ErrorAs(t, err, &missing)
+	assert.Equal(t, "pattern 'nope/*.yaml' of slice 'glob' matched no files for profile 'glob'", missing.Message)
+
+	// Globs and directories expand in order, and files already used are
+	// not passed again
+	trace, err = cfg.TraceProfile("all", discovery)
+	require.NoError(t, err)
+	postgres, redis := filepath.Join(dir, "db/postgres.yaml"), filepath.Join(dir, "db/redis.yml")
+	base := filepath.Join(dir, "compose.yaml")
+	assert.Equal(t, []string{base, logging, monitoring, postgres, redis}, trace.Slices[1].Files)
+	assert.Equal(t, []string{base, monitoring, logging, postgres, redis}, trace.Files)
+}
@@ -0,0 +1,232 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CurrentVersion is the newest dox.yaml version. Version 2 adds
+// default_profile and the slices mapping, and deprecates the keys they and
+// env_file replace.
+const CurrentVersion = 2
+
+// version2Keys returns the version 2 keys a config uses
+func (c *Config) version2Keys() []string {
+	var keys []string
+	if c.DefaultProfile != "" {
+		keys = append(keys, "default_profile")
+	}
+	if len(c.Slices) > 0 {
+		keys = append(keys, "slices")
+	}
+	return keys
+}
+
+// Deprecations lists the legacy keys a config uses, with their replacements.
+// dox config migrate rewrites them.
+func (c *Config) Deprecations() []string {
+	var messages []string
+	if c.Defaults.Profile != "" {
+		messages = append(messages, "defaults.profile is deprecated; use default_profile")
+	}
+	if c.Defaults.Slice != "" || c.Defaults.AutoDetect {
+		messages = append(messages, "defaults.slice and defaults.auto_detect have no effect")
+	}
+	if len(c.EnvFiles) > 0 {
+		messages = append(messages, "top-level env_files is deprecated; set env_file on each profile")
+	}
+	names := make([]string, 0, len(c.Profiles))
+	for name, profile := range c.Profiles {
+		if profile.Env != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		messages = append(messages, fmt.Sprintf("env of profile '%s' is deprecated; use env_file", name))
+	}
+	return messages
+}
+
+// Migrate rewrites the dox.yaml at path to the current version, keeping
+// comments, and returns the new content with a description of each change.
+// Only path itself is rewritten, not the files it includes.
+func Migrate(path string) ([]byte, []string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, &ConfigError{Path: path, Err: fmt.Errorf("failed to read config file: %w", err)}
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, nil, &ConfigError{Path: path, Err: fmt.Errorf("failed to parse config file: %w", err)}
+	}
+	if len(doc.Content) == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}}
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, nil, configErrorf(path, "top level is not a mapping")
+	}
+
+	var changes []string
+	current := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: strconv.Itoa(CurrentVersion)}
+	upgraded := false
+	if version := mappingValue(root, "version"); version == nil || version.Value != current.Value {
+		setAfter(root, "", "version", current)
+		changes = append(changes, fmt.Sprintf("set version: %d", CurrentVersion))
+		upgraded = true
+	}
+
+	if defaults := mappingValue(root, "defaults"); defaults != nil && defaults.Kind == yaml.MappingNode {
+		if profile := mappingValue(defaults, "profile"); profile != nil {
+			setAfter(root, "version", "default_profile", profile)
+			// The comment above defaults describes the profile it held
+			key := root.Content[mappingIndex(root, "default_profile")]
+			if comment := root.Content[mappingIndex(root, "defaults")].HeadComment; comment != "" && key.HeadComment == "" {
+				key.HeadComment = comment
+				root.Content[mappingIndex(root, "defaults")].HeadComment = ""
+			}
+			changes = append(changes, "moved defaults.profile to default_profile")
+		}
+		removeKey(root, "defaults")
+		changes = append(changes, "removed defaults")
+	}
+
+	envFiles := mappingValue(root, "env_files")
+	if profiles := mappingValue(root, "profiles"); profiles != nil && profiles.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(profiles.Content); i += 2 {
+			name, profile := profiles.Content[i].Value, profiles.Content[i+1]
+			j := mappingIndex(profile, "env")
+			if profile.Kind != yaml.MappingNode || j < 0 {
+				continue
+			}
+			env := profile.Content[j+1].Value
+			switch file := namedEnvFile(envFiles, env); {
+			case mappingValue(profile, "env_file") != nil:
+				removeKey(profile, "env")
+				changes = append(changes, fmt.Sprintf("removed env of profile '%s', overridden by its env_file", name))
+			case file == nil:
+				removeKey(profile, "env")
+				changes = append(changes, fmt.Sprintf("removed env '%s' of profile '%s', which env_files does not define", env, name))
+			default:
+				profile.Content[j].Value = "env_file"
+				profile.Content[j+1] = scalarNode(file.Value)
+				changes = append(changes, fmt.Sprintf("replaced env '%s' of profile '%s' with env_file %s", env, name, file.Value))
+			}
+		}
+	}
+	if envFiles != nil {
+		removeKey(root, "env_files")
+		changes = append(changes, "removed top-level env_files")
+	}
+
+	if upgraded && mappingValue(root, "slices") == nil {
+		discovery, err := DiscoverFiles(filepath.Dir(path))
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(discovery.Slices) > 0 {
+			slices := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			names := make([]string, 0, len(discovery.Slices))
+			for name := range discovery.Slices {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				files := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Style: yaml.FlowStyle}
+				files.Content = append(files.Content, scalarNode(filepath.Base(discovery.Slices[name])))
+				slices.Content = append(slices.Content, scalarNode(name), files)
+			}
+			setBefore(root, "profiles", "slices", slices)
+			changes = append(changes, fmt.Sprintf("listed the discovered slices under slices: %s", strings.Join(names, ", ")))
+		}
+	}
+
+	if len(changes) == 0 {
+		return data, nil, nil
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return nil, nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, nil, err
+	}
+	return buf.Bytes(), changes, nil
+}
+
+// namedEnvFile returns the path a legacy env_files entry gives a name
+func namedEnvFile(envFiles *yaml.Node, name string) *yaml.Node {
+	if envFiles == nil || envFiles.Kind != yaml.MappingNode {
+		return nil
+	}
+	return mappingValue(envFiles, name)
+}
+
+func scalarNode(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+}
+
+// setAfter sets key in a mapping, inserting it after the key after when new,
+// or first when after is not found
+func setAfter(node *yaml.Node, after, key string, value *yaml.Node) {
+	if i := mappingIndex(node, key); i >= 0 {
+		node.Content[i+1].Value = value.Value
+		node.Content[i+1].Tag = value.Tag
+		return
+	}
+	i := 0
+	if j := mappingIndex(node, after); j >= 0 {
+		i = j + 2
+	}
+	insertAt(node, i, key, value)
+}
+
+// setBefore inserts key in a mapping before the key before, or at the end
+func setBefore(node *yaml.Node, before, key string, value *yaml.Node) {
+	i := mappingIndex(node, before)
+	if i < 0 {
+		i = len(node.Content)
+	}
+	insertAt(node, i, key, value)
+}
+
+// insertAt inserts key at index i of a mapping's content. A new first key
+// takes over the comments that open the file.
+func insertAt(node *yaml.Node, i int, key string, value *yaml.Node) {
+	if i < 0 || i > len(node.Content) {
+		i = len(node.Content)
+	}
+	keyNode := scalarNode(key)
+	if i == 0 && len(node.Content) > 0 {
+		keyNode.HeadComment, node.Content[0].HeadComment = node.Content[0].HeadComment, ""
+	}
+	node.Content = append(node.Content[:i], append([]*yaml.Node{keyNode, value}, node.Content[i:]...)...)
+}
+
+// removeKey removes key from a mapping. Comments above the first key, which
+// open the file, move to the next key; other comments go with their key.
+func removeKey(node *yaml.Node, key string) {
+	i := mappingIndex(node, key)
+	if i < 0 {
+		return
+	}
+	if comment := node.Content[i].HeadComment; comment != "" && i == 0 && len(node.Content) > 2 {
+		next := node.Content[2]
+		if next.HeadComment != "" {
+			comment += "\n" + next.HeadComment
+		}
+		next.HeadComment = comment
+	}
+	node.Content = append(node.Content[:i], node.Content[i+2:]...)
+}
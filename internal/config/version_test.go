@@ -0,0 +1,124 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const legacyConfig = `# Shop services
+version: 1
+
+# Pick dev unless told otherwise
+defaults:
+  profile: dev
+
+env_files:
+  dev: .env.dev
+  prod: .env.production
+
+profiles:
+  dev:
+    slices: [dev] # local tooling
+    env: dev
+  prod:
+    slices: [prod]
+    env: prod
+  ci:
+    slices: [dev]
+    env: ci
+`
+
+func TestMigrate(t *testing.T) {
+	dir := t.TempDir()
+	writeConfigFiles(t, dir, map[string]string{
+		"dox.yaml":          legacyConfig,
+		"compose.yaml":      "services: {}\n",
+		"compose.dev.yaml":  "services: {}\n",
+		"compose.prod.yaml": "services: {}\n",
+	})
+	path := filepath.Join(dir, "dox.yaml")
+
+	legacy, err := LoadConfig(path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"defaults.profile is deprecated; use default_profile",
+		"top-level env_files is deprecated; set env_file on each profile",
+		"env of profile 'ci' is deprecated; use env_file",
+		"env of profile 'dev' is deprecated; use env_file",
+		"env of profile 'prod' is deprecated; use env_file",
+	}, legacy.Deprecations())
+
+	data, changes, err := Migrate(path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"set version: 2",
+		"moved defaults.profile to default_profile",
+		"removed defaults",
+		"replaced env 'dev' of profile 'dev' with env_file .env.dev",
+		"replaced env 'prod' of profile 'prod' with env_file .env.production",
+		"removed env 'ci' of profile 'ci', which env_files does not define",
+		"removed top-level env_files",
+		"listed the discovered slices under slices: dev, prod",
+	}, changes)
+	assert.Equal(t, `# Shop services
+version: 2
+# Pick dev unless told otherwise
+default_profile: dev
+slices:
+  dev: [compose.dev.yaml]
+  prod: [compose.prod.yaml]
+profiles:
+  dev:
+    slices: [dev] # local tooling
+    env_file: .env.dev
+  prod:
+    slices: [prod]
+    env_file: .env.production
+  ci:
+    slices: [dev]
+`, string(data))
+
+	require.NoError(t, os.WriteFile(path, data, 0644))
+	migrated, err := LoadConfig(path)
+	require.NoError(t, err)
+	assert.Empty(t, migrated.Deprecations())
+	assert.Equal(t, "dev", migrated.GetDefaultProfile())
+	assert.Equal(t, ".env.production", migrated.Profiles["prod"].EnvFile)
+
+	again, changes, err := Migrate(path)
+	require.NoError(t, err)
+	assert.Empty(t, changes, "a version 2 file is left alone")
+	assert.Equal(t, data, again)
+}
+
+func TestLoadConfig_Versions(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "dox.yaml")
+
+	writeConfigFiles(t, dir, map[string]string{"dox.yaml": "version: 2\ndefault_profile: dev\nslices:\n  dev: [compose.dev.yaml]\n"})
+	cfg, err := LoadConfig(path)
+	require.NoError(t, err)
+	assert.Equal(t, "dev", cfg.GetDefaultProfile())
+	assert.Equal(t, StringList{"compose.dev.yaml"}, cfg.Slices["dev"])
+
+	// Version 2 keys in older files apply with a warning
+	writeConfigFiles(t, dir, map[string]string{"dox.yaml": "version: 1\ndefault_profile: dev\n"})
+	cfg, err = LoadConfig(path)
+	require.NoError(t, err)
+	assert.Equal(t, "dev", cfg.GetDefaultProfile())
+	assert.Equal(t, []string{"dox.yaml: default_profile requires version: 2; run 'dox config migrate'"}, cfg.Warnings)
+
+	writeConfigFiles(t, dir, map[string]string{"dox.yaml": "slices:\n  dev: [compose.dev.yaml]\n"})
+	cfg, err = LoadConfig(path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"dox.yaml: slices requires version: 2; run 'dox config migrate'"}, cfg.Warnings)
+
+	writeConfigFiles(t, dir, map[string]string{"dox.yaml": "version: 3\n"})
+	_, err = LoadConfig(path)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unsupported config version: 3")
+}
@@ -0,0 +1,406 @@
+package doctor
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/AkaraChen/dox/internal/compose"
+	"github.com/AkaraChen/dox/internal/config"
+	"github.com/AkaraChen/dox/internal/project"
+)
+
+// Status is the outcome of a single check
+type Status string
+
+const (
+	StatusPass Status = "pass"
+	StatusWarn Status = "warn"
+	StatusFail Status = "fail"
+)
+
+// Result is the outcome of a single check with an optional fix hint
+type Result struct {
+	Name    string `json:"name"`
+	Status  Status `json:"status"`
+	Message string `json:"message"`
+	Hint    string `json:"hint,omitempty"`
+}
+
+// Report is the ordered list of check results
+type Report struct {
+	Results []Result `json:"results"`
+}
+
+// Env holds everything the checks inspect. LookPath and Run are injectable
+// so checks can be tested without a container runtime. RuntimeErr reports
+// why no Runtime could be selected.
+type Env struct {
+	Dir              string
+	Profile          string
+	Runtime          compose.Runtime
+	RuntimeErr       error
+	GlobalConfigPath string
+	HistoryPath      string
+	LookPath         func(file string) (string, error)
+	Run              func(dir string, argv []string) (string, error)
+}
+
+// NewEnv creates an Env that inspects the real system
+func NewEnv(dir, profile string, rt compose.Runtime) Env {
+	return Env{
+		Dir:              dir,
+		Profile:          profile,
+		Runtime:          rt,
+		GlobalConfigPath: project.GetGlobalConfigPath(),
+		HistoryPath:      project.GetHistoryPath(),
+		LookPath:         exec.LookPath,
+		Run:              runCommand,
+	}
+}
+
+func runCommand(dir string, argv []string) (string, error) {
+	c := exec.Command(argv[0], argv[1:]...)
+	c.Dir = dir
+	out, err := c.CombinedOutput()
+	return strings.TrimSpace(string(out)), err
+}
+
+// Run executes all checks in order
+func Run(env Env) *Report {
+	report := &Report{}
+	add := func(r Result) { report.Results = append(report.Results, r) }
+
+	runtimeOK := checkRuntime(env, add)
+	if runtimeOK {
+		add(checkDaemon(env))
+	}
+
+	cfg, configResult := checkConfig(env)
+	add(configResult)
+	if configResult.Status != StatusFail {
+		for _, r := range checkComposeFiles(env, cfg, runtimeOK) {
+			add(r)
+		}
+	}
+
+	for _, r := range checkGlobalConfig(env) {
+		add(r)
+	}
+	add(checkHistory(env))
+
+	return report
+}
+
+// Failed reports whether any check failed
+func (r *Report) Failed() bool {
+	for _, result := range r.Results {
+		if result.Status == StatusFail {
+			return true
+		}
+	}
+	return false
+}
+
+// Counts returns the number of results per status
+func (r *Report) Counts() map[Status]int {
+	counts := map[Status]int{}
+	for _, result := range r.Results {
+		counts[result.Status]++
+	}
+	return counts
+}
+
+// WriteText writes the report as a human-readable list
+func (r *Report) WriteText(w io.Writer) {
+	for _, result := range r.Results {
+		fmt.Fprintf(w, "[%s] %s: %s\n", strings.ToUpper(string(result.Status)), result.Name, result.Message)
+		if result.Hint != "" && result.Status != StatusPass {
+			fmt.Fprintf(w, "       hint: %s\n", result.Hint)
+		}
+	}
+	counts := r.Counts()
+	fmt.Fprintf(w, "\n%d passed, %d warnings, %d failed\n", counts[StatusPass], counts[StatusWarn], counts[StatusFail])
+}
+
+// WriteJSON writes the report as indented JSON
+func (r *Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+var versionRegex = regexp.MustCompile(`v?(\d+)\.(\d+)(?:\.(\d+))?`)
+
+// checkRuntime checks the runtime binary and its compose version
+func checkRuntime(env Env, add func(Result)) bool {
+	name := "runtime"
+	if env.Runtime == nil {
+		message := "no runtime selected"
+		if env.RuntimeErr != nil {
+			message = env.RuntimeErr.Error()
+		}
+		add(Result{
+			Name:    name,
+			Status:  StatusFail,
+			Message: message,
+			Hint:    fmt.Sprintf("set DOX_RUNTIME or runtime: to one of: %s", strings.Join(compose.RuntimeNames(), ", ")),
+		})
+		return false
+	}
+	argv := env.Runtime.Command()
+
+	path, err := env.LookPath(argv[0])
+	if err != nil {
+		add(Result{
+			Name:    name,
+			Status:  StatusFail,
+			Message: fmt.Sprintf("%s not found in PATH", argv[0]),
+			Hint:    fmt.Sprintf("install %s or select another runtime with DOX_RUNTIME (one of: %s)", argv[0], strings.Join(compose.RuntimeNames(), ", ")),
+		})
+		return false
+	}
+
+	out, err := env.Run(env.Dir, append(argv, "version"))
+	if err != nil {
+		add(Result{
+			Name:    name,
+			Status:  StatusFail,
+			Message: fmt.Sprintf("'%s version' failed: %s", strings.Join(argv, " "), firstLine(out)),
+			Hint:    "check that the compose plugin is installed for this runtime",
+		})
+		return false
+	}
+
+	result := Result{
+		Name:    name,
+		Status:  StatusPass,
+		Message: fmt.Sprintf("%s (%s): %s", env.Runtime.Name(), path, firstLine(out)),
+	}
+	if env.Runtime.Name() == compose.RuntimeDocker {
+		if major, ok := majorVersion(out); ok && major < 2 {
+			result.Status = StatusWarn
+			result.Hint = "dox expects Docker Compose v2; upgrade or set DOX_RUNTIME=docker-compose"
+		}
+	}
+	add(result)
+	return true
+}
+
+// checkDaemon checks that the container engine answers
+func checkDaemon(env Env) Result {
+	argv := env.Runtime.InfoCommand()
+	if _, err := env.LookPath(argv[0]); err != nil {
+		return Result{
+			Name:    "daemon",
+			Status:  StatusWarn,
+			Message: fmt.Sprintf("%s not found in PATH, cannot check the engine", argv[0]),
+		}
+	}
+
+	out, err := env.Run(env.Dir, argv)
+	if err != nil {
+		return Result{
+			Name:    "daemon",
+			Status:  StatusFail,
+			Message: fmt.Sprintf("'%s' failed: %s", strings.Join(argv, " "), firstLine(out)),
+			Hint:    "start the container engine and check that your user may access it",
+		}
+	}
+	return Result{Name: "daemon", Status: StatusPass, Message: fmt.Sprintf("%s is reachable", argv[0])}
+}
+
+// checkConfig checks that dox.yaml parses and the profile resolves
+func checkConfig(env Env) (*config.Config, Result) {
+	cfg, path, err := config.LoadConfigForProfile(env.Dir, env.Profile)
+	if err != nil {
+		return nil, Result{Name: "config", Status: StatusFail, Message: err.Error(), Hint: "fix the syntax error in dox.yaml"}
+	}
+	if cfg == nil {
+		return nil, Result{Name: "config", Status: StatusPass, Message: "no dox.yaml, using auto-discovery"}
+	}
+
+	profile := env.Profile
+	if profile == "" {
+		profile = cfg.GetDefaultProfile()
+	}
+	if profile != "" {
+		if _, ok := cfg.Profiles[profile]; !ok {
+			return cfg, Result{
+				Name:    "config",
+				Status:  StatusFail,
+				Message: fmt.Sprintf("profile '%s' not found in %s", profile, path),
+				Hint:    "define the profile or fix default_profile",
+			}
+		}
+	}
+	return cfg, Result{Name: "config", Status: StatusPass, Message: fmt.Sprintf("%s is valid", path)}
+}
+
+// checkComposeFiles checks the resolved compose and env files
+func checkComposeFiles(env Env, cfg *config.Config, runtimeOK bool) []Result {
+	profile := env.Profile
+	if profile == "" && cfg != nil {
+		profile = cfg.GetDefaultProfile()
+	}
+	builder := compose.NewBuilder(env.Dir, cfg, profile)
+	if env.Runtime != nil {
+		builder.SetRuntime(env.Runtime)
+	}
+
+	files, err := builder.Files()
+	if err != nil {
+		return []Result{{
+			Name:    "compose files",
+			Status:  StatusFail,
+			Message: err.Error(),
+			Hint:    "add compose.yaml or fix the slices referenced by the profile",
+		}}
+	}
+
+	results := []Result{checkComposeSyntax(env, builder, files, runtimeOK)}
+	envFiles, skipped, err := builder.EnvFiles()
+	if err != nil {
+		return append(results, Result{Name: "env file", Status: StatusFail, Message: err.Error()})
+	}
+	for _, envFile := range envFiles {
+		results = append(results, checkEnvFile(env, envFile))
+	}
+	for _, envFile := range skipped {
+		results = append(results, Result{Name: "env file", Status: StatusPass, Message: fmt.Sprintf("%s is optional and skipped", envFile)})
+	}
+	return results
+}
+
+// checkComposeSyntax checks that the files exist and that compose accepts
+// them with the project name and env files of the profile
+func checkComposeSyntax(env Env, builder *compose.Builder, files []string, runtimeOK bool) Result {
+	for _, file := range files {
+		if _, err := os.Stat(file); err != nil {
+			return Result{Name: "compose files", Status: StatusFail, Message: fmt.Sprintf("%s does not exist", file)}
+		}
+	}
+
+	if !runtimeOK {
+		return Result{
+			Name:    "compose files",
+			Status:  StatusWarn,
+			Message: fmt.Sprintf("%d files found, syntax not checked without a runtime", len(files)),
+		}
+	}
+
+	argv, err := builder.Build("config", []string{"-q"})
+	if err != nil {
+		return Result{Name: "compose files", Status: StatusFail, Message: err.Error()}
+	}
+	if out, err := env.Run(env.Dir, argv); err != nil {
+		return Result{
+			Name:    "compose files",
+			Status:  StatusFail,
+			Message: fmt.Sprintf("compose config failed: %s", firstLine(out)),
+			Hint:    fmt.Sprintf("run '%s' for details", strings.Join(argv[:len(argv)-1], " ")),
+		}
+	}
+
+	return Result{Name: "compose files", Status: StatusPass, Message: fmt.Sprintf("%d files are valid", len(files))}
+}
+
+// checkEnvFile checks that the profile's env file exists
+func checkEnvFile(env Env, envFile string) Result {
+	path := envFile
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(env.Dir, path)
+	}
+	if _, err := os.Stat(path); err != nil {
+		return Result{
+			Name:    "env file",
+			Status:  StatusFail,
+			Message: fmt.Sprintf("%s does not exist", envFile),
+			Hint:    "create the env file or remove it from the profile",
+		}
+	}
+	return Result{Name: "env file", Status: StatusPass, Message: fmt.Sprintf("%s exists", envFile)}
+}
+
+// checkGlobalConfig checks the global config and registered project paths
+func checkGlobalConfig(env Env) []Result {
+	cfg, err := project.LoadGlobalConfig(env.GlobalConfigPath)
+	if err != nil {
+		return []Result{{Name: "global config", Status: StatusFail, Message: err.Error(), Hint: "fix the syntax error in " + env.GlobalConfigPath}}
+	}
+	if cfg == nil {
+		return []Result{{Name: "global config", Status: StatusPass, Message: "no global config"}}
+	}
+
+	results := []Result{{Name: "global config", Status: StatusPass, Message: fmt.Sprintf("%s is valid", env.GlobalConfigPath)}}
+	for _, info := range cfg.ListProjects() {
+		if _, err := os.Stat(info.Path); err != nil {
+			results = append(results, Result{
+				Name:    "project @" + info.Name,
+				Status:  StatusWarn,
+				Message: fmt.Sprintf("path %s does not exist", info.Path),
+				Hint:    "update or remove the project in " + env.GlobalConfigPath,
+			})
+		}
+	}
+	return results
+}
+
+// checkHistory checks that the history file can be written, without
+// creating it or its directory
+func checkHistory(env Env) Result {
+	hint := "commands will not be recorded in history"
+
+	if info, err := os.Stat(env.HistoryPath); err == nil {
+		if info.IsDir() {
+			return Result{Name: "history", Status: StatusWarn, Message: fmt.Sprintf("%s is a directory", env.HistoryPath), Hint: hint}
+		}
+		f, err := os.OpenFile(env.HistoryPath, os.O_WRONLY, 0)
+		if err != nil {
+			return Result{Name: "history", Status: StatusWarn, Message: err.Error(), Hint: hint}
+		}
+		f.Close()
+		return Result{Name: "history", Status: StatusPass, Message: fmt.Sprintf("%s is writable", env.HistoryPath)}
+	}
+
+	// The file is created on first use in the nearest existing directory
+	dir := filepath.Dir(env.HistoryPath)
+	for {
+		info, err := os.Stat(dir)
+		if err == nil {
+			if !info.IsDir() {
+				return Result{Name: "history", Status: StatusWarn, Message: fmt.Sprintf("%s is not a directory", dir), Hint: hint}
+			}
+			if info.Mode().Perm()&0222 == 0 {
+				return Result{Name: "history", Status: StatusWarn, Message: fmt.Sprintf("%s is not writable", dir), Hint: hint}
+			}
+			break
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+	return Result{Name: "history", Status: StatusPass, Message: fmt.Sprintf("%s will be created on first use", env.HistoryPath)}
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(s), "\n")
+	return line
+}
+
+func majorVersion(s string) (int, bool) {
+	m := versionRegex.FindStringSubmatch(s)
+	if m == nil {
+		return 0, false
+	}
+	major, err := strconv.Atoi(m[1])
+	return major, err == nil
+}
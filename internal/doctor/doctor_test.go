@@ -0,0 +1,206 @@
+package doctor
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/AkaraChen/dox/internal/compose"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupFixture(name string) string {
+	return filepath.Join("..", "..", "test", "fixtures", name)
+}
+
+// fakeEnv returns an Env where every binary exists and every command succeeds
+// unless listed in failing
+func fakeEnv(t *testing.T, dir string, failing ...string) Env {
+	tmp := t.TempDir()
+	return Env{
+		Dir:              dir,
+		Runtime:          compose.DefaultRuntime(),
+		GlobalConfigPath: filepath.Join(tmp, "config.yaml"),
+		HistoryPath:      filepath.Join(tmp, "cache", "history.yaml"),
+		LookPath:         func(file string) (string, error) { return "/usr/bin/" + file, nil },
+		Run: func(dir string, argv []string) (string, error) {
+			line := strings.Join(argv, " ")
+			for _, f := range failing {
+				if strings.Contains(line, f) {
+					return "boom", errors.New("exit status 1")
+				}
+			}
+			return "Docker Compose version v2.24.0", nil
+		},
+	}
+}
+
+func findResult(t *testing.T, report *Report, name string) Result {
+	for _, r := range report.Results {
+		if r.Name == name {
+			return r
+		}
+	}
+	t.Fatalf("no result named %q", name)
+	return Result{}
+}
+
+func TestRun_AllPass(t *testing.T) {
+	report := Run(fakeEnv(t, setupFixture("with-env")))
+
+	assert.False(t, report.Failed())
+	assert.Equal(t, StatusPass, findResult(t, report, "runtime").Status)
+	assert.Equal(t, StatusPass, findResult(t, report, "daemon").Status)
+	assert.Equal(t, StatusPass, findResult(t, report, "compose files").Status)
+	assert.Equal(t, StatusPass, findResult(t, report, "history").Status)
+}
+
+func TestRun_RuntimeMissing(t *testing.T) {
+	env := fakeEnv(t, setupFixture("simple"))
+	env.LookPath = func(string) (string, error) { return "", errors.New("not found") }
+
+	report := Run(env)
+	assert.True(t, report.Failed())
+	runtime := findResult(t, report, "runtime")
+	assert.Equal(t, StatusFail, runtime.Status)
+	assert.Contains(t, runtime.Hint, "DOX_RUNTIME")
+	assert.Equal(t, StatusWarn, findResult(t, report, "compose files").Status)
+}
+
+func TestRun_ComposeV1Warns(t *testing.T) {
+	env := fakeEnv(t, setupFixture("simple"))
+	env.Run = func(string, []string) (string, error) { return "docker-compose version 1.29.2", nil }
+
+	report := Run(env)
+	assert.Equal(t, StatusWarn, findResult(t, report, "runtime").Status)
+}
+
+func TestRun_DaemonUnreachable(t *testing.T) {
+	report := Run(fakeEnv(t, setupFixture("simple"), "info"))
+	assert.Equal(t, StatusFail, findResult(t, report, "daemon").Status)
+}
+
+func TestRun_ComposeConfigFails(t *testing.T) {
+	report := Run(fakeEnv(t, setupFixture("simple"), "config -q"))
+	result := findResult(t, report, "compose files")
+	assert.Equal(t, StatusFail, result.Status)
+	assert.Contains(t, result.Message, "boom")
+}
+
+func TestRun_ComposeConfigUsesProfile(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"compose.yaml":      "services: {}\n",
+		"compose.prod.yaml": "services: {}\n",
+		".env.prod":         "TAG=stable\n",
+		"dox.yaml":          "version: 2\nprofiles:\n  prod:\n    slices: [prod]\n    env_file: .env.prod\n    project_name: shop-prod\n",
+	} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+
+	env := fakeEnv(t, dir)
+	env.Profile = "prod"
+	var configArgv []string
+	run := env.Run
+	env.Run = func(dir string, argv []string) (string, error) {
+		if slices.Contains(argv, "config") {
+			configArgv = argv
+		}
+		return run(dir, argv)
+	}
+
+	report := Run(env)
+	assert.Equal(t, StatusPass, findResult(t, report, "compose files").Status)
+	line := strings.Join(configArgv, " ")
+	assert.Contains(t, line, "-f "+filepath.Join(dir, "compose.prod.yaml"))
+	assert.Contains(t, line, "-p shop-prod")
+	assert.Contains(t, line, "--env-file .env.prod")
+	assert.True(t, strings.HasSuffix(line, " config -q"), line)
+}
+
+func TestRun_RuntimeNotResolved(t *testing.T) {
+	env := fakeEnv(t, setupFixture("simple"))
+	env.Runtime = nil
+	env.RuntimeErr = errors.New("unknown runtime 'containerd'")
+
+	report := Run(env)
+	runtime := findResult(t, report, "runtime")
+	assert.Equal(t, StatusFail, runtime.Status)
+	assert.Equal(t, "unknown runtime 'containerd'", runtime.Message)
+	assert.Equal(t, StatusPass, findResult(t, report, "config").Status, "the other checks still run")
+	assert.Equal(t, StatusWarn, findResult(t, report, "compose files").Status)
+}
+
+func TestCheckHistory_DoesNotCreate(t *testing.T) {
+	env := fakeEnv(t, setupFixture("simple"))
+
+	result := checkHistory(env)
+	assert.Equal(t, StatusPass, result.Status)
+	assert.Contains(t, result.Message, "will be created on first use")
+	assert.NoDirExists(t, filepath.Dir(env.HistoryPath))
+
+	require.NoError(t, os.MkdirAll(filepath.Dir(env.HistoryPath), 0755))
+	require.NoError(t, os.WriteFile(env.HistoryPath, []byte("entries: []\n"), 0644))
+	result = checkHistory(env)
+	assert.Equal(t, StatusPass, result.Status)
+	assert.Contains(t, result.Message, "is writable")
+	data, err := os.ReadFile(env.HistoryPath)
+	require.NoError(t, err)
+	assert.Equal(t, "entries: []\n", string(data))
+}
+
+func TestRun_InvalidConfig(t *testing.T) {
+	report := Run(fakeEnv(t, setupFixture(filepath.Join("edge-cases", "invalid-yaml"))))
+	assert.Equal(t, StatusFail, findResult(t, report, "config").Status)
+}
+
+func TestRun_MissingEnvFile(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "compose.yaml"), []byte("services: {}\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "dox.yaml"), []byte(`
+version: 1
+defaults:
+  profile: dev
+profiles:
+  dev:
+    env_file: .env.dev
+`), 0644))
+
+	report := Run(fakeEnv(t, dir))
+	assert.Equal(t, StatusFail, findResult(t, report, "env file").Status)
+}
+
+func TestRun_MissingProjectPath(t *testing.T) {
+	env := fakeEnv(t, setupFixture("simple"))
+	content := []byte("projects:\n  ghost:\n    path: /nonexistent/ghost\n")
+	require.NoError(t, os.WriteFile(env.GlobalConfigPath, content, 0644))
+
+	report := Run(env)
+	assert.Equal(t, StatusWarn, findResult(t, report, "project @ghost").Status)
+	assert.False(t, report.Failed())
+}
+
+func TestReport_Output(t *testing.T) {
+	report := &Report{Results: []Result{
+		{Name: "runtime", Status: StatusPass, Message: "ok"},
+		{Name: "daemon", Status: StatusFail, Message: "down", Hint: "start it"},
+	}}
+
+	var text bytes.Buffer
+	report.WriteText(&text)
+	assert.Contains(t, text.String(), "[FAIL] daemon: down")
+	assert.Contains(t, text.String(), "hint: start it")
+	assert.Contains(t, text.String(), "1 passed, 0 warnings, 1 failed")
+
+	var out bytes.Buffer
+	require.NoError(t, report.WriteJSON(&out))
+	var decoded Report
+	require.NoError(t, json.Unmarshal(out.Bytes(), &decoded))
+	assert.Equal(t, report.Results, decoded.Results)
+}
@@ -0,0 +1,97 @@
+package environ
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/AkaraChen/dox/internal/ansi"
+)
+
+// Change is a variable that differs between two environments. From or To is
+// nil when only one environment defines the variable.
+type Change struct {
+	Key  string `json:"key"`
+	From *Var   `json:"from,omitempty"`
+	To   *Var   `json:"to,omitempty"`
+}
+
+// Diff is the difference between the environments of two profiles
+type Diff struct {
+	From    string   `json:"from"`
+	To      string   `json:"to"`
+	Changes []Change `json:"changes"`
+}
+
+// DiffEnvironments compares two environments by value, sorted by key.
+// Variables with the same value but a different source are not changes.
+func DiffEnvironments(fromName, toName string, from, to *Environment) *Diff {
+	keys := map[string]bool{}
+	for key := range from.Vars {
+		keys[key] = true
+	}
+	for key := range to.Vars {
+		keys[key] = true
+	}
+	sorted := make([]string, 0, len(keys))
+	for key := range keys {
+		sorted = append(sorted, key)
+	}
+	sort.Strings(sorted)
+
+	d := &Diff{From: fromName, To: toName, Changes: []Change{}}
+	for _, key := range sorted {
+		a, b := from.Vars[key], to.Vars[key]
+		if a != nil && b != nil && a.Value == b.Value {
+			continue
+		}
+		d.Changes = append(d.Changes, Change{Key: key, From: a, To: b})
+	}
+	return d
+}
+
+// WriteText writes the diff for humans, with ANSI colors when color is set.
+// Secret values are masked unless reveal is set.
+func (d *Diff) WriteText(w io.Writer, color, reveal bool) {
+	paint := func(code, s string) string { return ansi.Paint(color, code, s) }
+
+	if len(d.Changes) == 0 {
+		fmt.Fprintf(w, "No differences between %s and %s\n", d.From, d.To)
+		return
+	}
+
+	fmt.Fprintf(w, "--- %s\n+++ %s\n", d.From, d.To)
+	for _, c := range d.Changes {
+		switch {
+		case c.From == nil:
+			fmt.Fprintln(w, paint(ansi.Green, fmt.Sprintf("+ %s=%s  # %s", c.Key, c.To.Display(reveal), c.To.Source)))
+		case c.To == nil:
+			fmt.Fprintln(w, paint(ansi.Red, fmt.Sprintf("- %s=%s  # %s", c.Key, c.From.Display(reveal), c.From.Source)))
+		default:
+			fmt.Fprintln(w, paint(ansi.Yellow, fmt.Sprintf("~ %s: %s -> %s  # %s", c.Key, c.From.Display(reveal), c.To.Display(reveal), c.To.Source)))
+		}
+	}
+}
+
+// WriteJSON writes the diff as indented JSON. Secret values are masked
+// unless reveal is set.
+func (d *Diff) WriteJSON(w io.Writer, reveal bool) error {
+	masked := &Diff{From: d.From, To: d.To, Changes: make([]Change, len(d.Changes))}
+	for i, c := range d.Changes {
+		masked.Changes[i] = Change{Key: c.Key, From: c.From.Masked(reveal), To: c.To.Masked(reveal)}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(masked)
+}
+
+// Masked returns a copy of v with its value as Display returns it
+func (v *Var) Masked(reveal bool) *Var {
+	if v == nil {
+		return nil
+	}
+	c := *v
+	c.Value = v.Display(reveal)
+	return &c
+}
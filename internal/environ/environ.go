@@ -0,0 +1,162 @@
+package environ
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Var is a variable in the resolved environment. Overrides lists the
+// sources of earlier values that this one replaced.
+type Var struct {
+	Key       string   `json:"key"`
+	Value     string   `json:"value"`
+	Source    string   `json:"source"`
+	Overrides []string `json:"overrides,omitempty"`
+}
+
+// Environment is the set of variables compose sees, with where each came from
+type Environment struct {
+	Vars    map[string]*Var
+	Missing []string // required env files that do not exist
+}
+
+// New creates an empty environment
+func New() *Environment {
+	return &Environment{Vars: map[string]*Var{}}
+}
+
+// Set assigns a variable, recording the source it overrides
+func (e *Environment) Set(key, value, source string) {
+	if v, ok := e.Vars[key]; ok {
+		v.Overrides = append(v.Overrides, v.Source)
+		v.Value = value
+		v.Source = source
+		return
+	}
+	e.Vars[key] = &Var{Key: key, Value: value, Source: source}
+}
+
+// Lookup returns the value of a variable
+func (e *Environment) Lookup(key string) (string, bool) {
+	v, ok := e.Vars[key]
+	if !ok {
+		return "", false
+	}
+	return v.Value, true
+}
+
+// Sorted returns the variables sorted by key
+func (e *Environment) Sorted() []*Var {
+	vars := make([]*Var, 0, len(e.Vars))
+	for _, v := range e.Vars {
+		vars = append(vars, v)
+	}
+	sort.Slice(vars, func(i, j int) bool { return vars[i].Key < vars[j].Key })
+	return vars
+}
+
+// LoadFile sets the variables of an env file, with the file as their source
+func (e *Environment) LoadFile(path, source string) error {
+	entries, err := ParseFile(path)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		e.Set(entry[0], entry[1], source)
+	}
+	return nil
+}
+
+// ParseFile reads a dotenv file as ordered key/value pairs. It accepts
+// comments, blank lines, an optional "export " prefix, and single or double
+// quoted values.
+func ParseFile(path string) ([][2]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries [][2]string
+	scanner := bufio.NewScanner(f)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || !validKey.MatchString(key) {
+			return nil, fmt.Errorf("%s:%d: invalid line %q", path, lineNo, line)
+		}
+		entries = append(entries, [2]string{key, parseValue(strings.TrimSpace(value))})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+var validKey = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]*$`)
+
+// parseValue unquotes a value and strips trailing comments from unquoted ones
+func parseValue(value string) string {
+	if len(value) >= 2 {
+		switch quote := value[0]; quote {
+		case '"', '\'':
+			for end := 1; end < len(value); end++ {
+				if quote == '"' && value[end] == '\\' {
+					end++ // skip the escaped character
+					continue
+				}
+				if value[end] == quote {
+					inner := value[1:end]
+					if quote == '"' {
+						inner = strings.NewReplacer(`\n`, "\n", `\"`, `"`, `\\`, `\`).Replace(inner)
+					}
+					return inner
+				}
+			}
+		}
+	}
+	if i := strings.Index(value, " #"); i >= 0 {
+		value = strings.TrimSpace(value[:i])
+	}
+	return value
+}
+
+// secretKey matches variable names that usually hold credentials
+var secretKey = regexp.MustCompile(`(?i)(PASSWORD|PASSWD|PASS$|SECRET|TOKEN|API_?KEY|PRIVATE|CREDENTIAL|AUTH)`)
+
+// IsSecret reports whether a variable looks like it holds a secret, by its
+// name or by a value such as a private key or a URL with a password
+func IsSecret(key, value string) bool {
+	return secretKey.MatchString(key) ||
+		strings.HasPrefix(value, "-----BEGIN") ||
+		urlPassword.MatchString(value)
+}
+
+var urlPassword = regexp.MustCompile(`://[^/\s:@]+:[^/\s@]+@`)
+
+// Mask hides a secret value
+func Mask(value string) string {
+	if value == "" {
+		return ""
+	}
+	return "********"
+}
+
+// Display returns the value to print, masked when it looks like a secret
+// and reveal is not set
+func (v *Var) Display(reveal bool) string {
+	if !reveal && IsSecret(v.Key, v.Value) {
+		return Mask(v.Value)
+	}
+	return v.Value
+}
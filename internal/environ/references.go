@@ -0,0 +1,79 @@
+package environ
+
+import (
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Reference is a variable interpolated in compose files
+type Reference struct {
+	Name       string   `json:"name"`
+	Files      []string `json:"files"`
+	HasDefault bool     `json:"has_default,omitempty"`
+	Required   bool     `json:"required,omitempty"`
+}
+
+// interpolation matches $$ escapes, ${VAR...} and $VAR
+var interpolation = regexp.MustCompile(`\$\$|\$\{([A-Za-z_][A-Za-z0-9_]*)([^}]*)\}|\$([A-Za-z_][A-Za-z0-9_]*)`)
+
+// References returns the variables interpolated in the given compose files,
+// sorted by name. ${VAR:-default} and ${VAR-default} count as having a
+// default, ${VAR:?error} and ${VAR?error} as required.
+func References(files []string) ([]Reference, error) {
+	refs := map[string]*Reference{}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range interpolation.FindAllStringSubmatch(string(data), -1) {
+			name, modifier := m[1], m[2]
+			if name == "" {
+				name = m[3]
+			}
+			if name == "" {
+				continue // $$ escape
+			}
+
+			ref, ok := refs[name]
+			if !ok {
+				ref = &Reference{Name: name}
+				refs[name] = ref
+			}
+			if len(ref.Files) == 0 || ref.Files[len(ref.Files)-1] != file {
+				ref.Files = append(ref.Files, file)
+			}
+			switch op := strings.TrimPrefix(modifier, ":"); {
+			case strings.HasPrefix(op, "-"):
+				ref.HasDefault = true
+			case strings.HasPrefix(op, "?"):
+				ref.Required = true
+			}
+		}
+	}
+
+	names := make([]string, 0, len(refs))
+	for name := range refs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	result := make([]Reference, 0, len(names))
+	for _, name := range names {
+		result = append(result, *refs[name])
+	}
+	return result, nil
+}
+
+// Unset returns the references that env does not define
+func (e *Environment) Unset(refs []Reference) []Reference {
+	var unset []Reference
+	for _, ref := range refs {
+		if _, ok := e.Vars[ref.Name]; !ok {
+			unset = append(unset, ref)
+		}
+	}
+	return unset
+}
@@ -0,0 +1,506 @@
+package lint
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/AkaraChen/dox/internal/config"
+	"github.com/AkaraChen/dox/internal/model"
+)
+
+// Severity is the level a rule reports at
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+	SeverityOff     Severity = "off"
+)
+
+// Rule IDs
+const (
+	RuleConflictingImage  = "conflicting-image"
+	RulePortCollision     = "port-collision"
+	RuleMissingDependency = "missing-dependency"
+	RuleUnreferencedSlice = "unreferenced-slice"
+	RuleExtensionTwins    = "yml-yaml-twins"
+	RuleMissingEnvFile    = "missing-env-file"
+	RuleUnresolvedProfile = "unresolved-profile"
+)
+
+// Output formats supported by Report.Write
+const (
+	FormatText  = "text"
+	FormatJSON  = "json"
+	FormatSARIF = "sarif"
+)
+
+// Rule describes a lint rule and its default severity
+type Rule struct {
+	ID          string
+	Description string
+	Severity    Severity
+}
+
+// Rules lists every rule in the order they run
+var Rules = []Rule{
+	{RuleUnresolvedProfile, "A profile cannot be resolved to compose files", SeverityError},
+	{RuleConflictingImage, "The same service sets different images in the compose files a profile combines", SeverityWarning},
+	{RulePortCollision, "Two services publish the same host port within a profile", SeverityError},
+	{RuleMissingDependency, "A depends_on target is not defined in a profile", SeverityError},
+	{RuleUnreferencedSlice, "A slice or slice file is not used by any profile", SeverityWarning},
+	{RuleExtensionTwins, "Both .yml and .yaml variants of a compose file exist", SeverityWarning},
+	{RuleMissingEnvFile, "A referenced env file does not exist", SeverityError},
+}
+
+// Finding is a single problem reported by a rule
+type Finding struct {
+	Rule     string   `json:"rule"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+	File     string   `json:"file"`
+	Profile  string   `json:"profile,omitempty"`
+	Service  string   `json:"service,omitempty"`
+}
+
+// Report is the result of a lint run
+type Report struct {
+	Dir      string    `json:"-"`
+	Findings []Finding `json:"findings"`
+}
+
+// linter holds the state of a single lint run
+type linter struct {
+	dir       string
+	cfg       *config.Config
+	discovery *config.Discovery
+	severity  map[string]Severity
+	report    *Report
+	seen      map[string]bool
+}
+
+// profileFiles is the file set of a profile, or of auto-discovery when Name is empty
+type profileFiles struct {
+	Name     string
+	Files    []string
+	EnvFile  string
+	EnvFiles []string
+}
+
+// Run lints the compose files and dox.yaml in dir. cfg may be nil.
+func Run(dir string, cfg *config.Config) (*Report, error) {
+	discovery, err := config.DiscoverFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	severity, err := severities(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &linter{
+		dir:       dir,
+		cfg:       cfg,
+		discovery: discovery,
+		severity:  severity,
+		report:    &Report{Dir: dir, Findings: []Finding{}},
+		seen:      map[string]bool{},
+	}
+
+	profiles := l.profiles()
+	l.checkConflictingImages(profiles)
+	for _, p := range profiles {
+		project, err := model.Load(p.Files...)
+		if err != nil {
+			l.add(RuleUnresolvedProfile, l.configFile(), p.Name, "", "%s", err)
+			continue
+		}
+		l.checkPortCollisions(p, project)
+		l.checkDependencies(p, project)
+		l.checkEnvFiles(p, project)
+	}
+	l.checkUnreferencedSlices()
+	l.checkExtensionTwins()
+
+	return l.report, nil
+}
+
+// severities returns the effective severity of every rule
+func severities(cfg *config.Config) (map[string]Severity, error) {
+	severity := map[string]Severity{}
+	for _, rule := range Rules {
+		severity[rule.ID] = rule.Severity
+	}
+	if cfg == nil {
+		return severity, nil
+	}
+
+	for id, level := range cfg.Lint.Rules {
+		if _, ok := severity[id]; !ok {
+			return nil, &config.ConfigError{Path: cfg.Path, Err: fmt.Errorf("unknown lint rule '%s'", id)}
+		}
+		switch s := Severity(level); s {
+		case SeverityError, SeverityWarning, SeverityOff:
+			severity[id] = s
+		default:
+			return nil, &config.ConfigError{Path: cfg.Path, Err: fmt.Errorf("lint rule '%s': invalid severity '%s' (expected error, warning or off)", id, level)}
+		}
+	}
+	return severity, nil
+}
+
+// profiles resolves every profile in dox.yaml, or auto-discovery without one
+func (l *linter) profiles() []profileFiles {
+	if l.cfg == nil || len(l.cfg.Profiles) == 0 {
+		if len(l.discovery.Files) == 0 {
+			return nil
+		}
+		return []profileFiles{{Files: l.discovery.Files}}
+	}
+
+	names := make([]string, 0, len(l.cfg.Profiles))
+	for name := range l.cfg.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var profiles []profileFiles
+	for _, name := range names {
+		files, envFile, err := l.cfg.ResolveProfile(name, l.discovery)
+		if err != nil {
+			l.add(RuleUnresolvedProfile, l.configFile(), name, "", "%s", err)
+			continue
+		}
+		effective, err := l.cfg.EffectiveProfile(name)
+		if err != nil {
+			l.add(RuleUnresolvedProfile, l.configFile(), name, "", "%s", err)
+			continue
+		}
+		profiles = append(profiles, profileFiles{Name: name, Files: files, EnvFile: envFile, EnvFiles: effective.EnvFiles})
+	}
+	return profiles
+}
+
+// checkConflictingImages flags services whose image differs between the
+// files a profile combines
+func (l *linter) checkConflictingImages(profiles []profileFiles) {
+	loaded := map[string]*model.Project{}
+	images := map[string]map[string][]string{} // service -> image -> files
+	for _, p := range profiles {
+		for _, file := range p.Files {
+			project, ok := loaded[file]
+			if !ok {
+				project, _ = model.Load(file)
+				loaded[file] = project
+			}
+			if project == nil {
+				continue
+			}
+			for name, svc := range project.Services {
+				if svc.Image == "" {
+					continue
+				}
+				if images[name] == nil {
+					images[name] = map[string][]string{}
+				}
+				images[name][svc.Image] = append(images[name][svc.Image], file)
+			}
+		}
+		l.reportConflictingImages(images)
+		clear(images)
+	}
+}
+
+// reportConflictingImages adds a finding for each service with more than
+// one image
+func (l *linter) reportConflictingImages(images map[string]map[string][]string) {
+	for _, name := range sortedKeys(images) {
+		if len(images[name]) < 2 {
+			continue
+		}
+		var parts []string
+		var last string
+		for _, image := range sortedKeys(images[name]) {
+			files := images[name][image]
+			parts = append(parts, fmt.Sprintf("%s (%s)", image, strings.Join(l.relAll(files), ", ")))
+			last = files[len(files)-1]
+		}
+		l.add(RuleConflictingImage, last, "", name, "service '%s' sets different images: %s", name, strings.Join(parts, ", "))
+	}
+}
+
+// checkPortCollisions flags host ports published by more than one service
+func (l *linter) checkPortCollisions(p profileFiles, project *model.Project) {
+	owners := map[string][]string{}
+	for _, name := range project.ServiceNames() {
+		for _, port := range project.Services[name].Ports {
+			if port.Published == "" {
+				continue
+			}
+			protocol := port.Protocol
+			if protocol == "" {
+				protocol = "tcp"
+			}
+			key := fmt.Sprintf("%s:%s/%s", hostIP(port.HostIP), port.Published, protocol)
+			if len(owners[key]) == 0 || owners[key][len(owners[key])-1] != name {
+				owners[key] = append(owners[key], name)
+			}
+		}
+	}
+
+	for _, key := range sortedKeys(owners) {
+		services := owners[key]
+		if len(services) < 2 {
+			continue
+		}
+		hostPort := strings.TrimPrefix(key, ":")
+		file := lastSource(project.Services[services[len(services)-1]])
+		l.add(RulePortCollision, file, p.Name, services[len(services)-1], "host port %s is published by %s%s", hostPort, strings.Join(services, " and "), l.inProfile(p))
+	}
+}
+
+// hostIP normalizes the addresses that bind every interface to ""
+func hostIP(ip string) string {
+	switch ip {
+	case "0.0.0.0", "::", "[::]":
+		return ""
+	}
+	return ip
+}
+
+// checkDependencies flags depends_on targets the profile does not define
+func (l *linter) checkDependencies(p profileFiles, project *model.Project) {
+	for _, name := range project.ServiceNames() {
+		svc := project.Services[name]
+		deps := make([]string, 0, len(svc.DependsOn))
+		for dep := range svc.DependsOn {
+			deps = append(deps, dep)
+		}
+		sort.Strings(deps)
+		for _, dep := range deps {
+			if _, ok := project.Services[dep]; !ok {
+				l.add(RuleMissingDependency, lastSource(svc), p.Name, name, "service '%s' depends on '%s', which is not defined%s", name, dep, l.inProfile(p))
+			}
+		}
+	}
+}
+
+// checkEnvFiles flags missing env files of the profile and its services
+func (l *linter) checkEnvFiles(p profileFiles, project *model.Project) {
+	if p.EnvFile != "" && !l.exists(p.EnvFile) {
+		l.add(RuleMissingEnvFile, l.configFile(), p.Name, "", "env file '%s' of profile '%s' does not exist", p.EnvFile, p.Name)
+	}
+	for _, entry := range p.EnvFiles {
+		if path, optional := config.ParseEnvFileEntry(entry); !optional && !l.exists(path) {
+			l.add(RuleMissingEnvFile, l.configFile(), p.Name, "", "env file '%s' of profile '%s' does not exist", path, p.Name)
+		}
+	}
+
+	for _, name := range project.ServiceNames() {
+		svc := project.Services[name]
+		for _, envFile := range svc.EnvFiles {
+			if !l.exists(envFile) {
+				l.add(RuleMissingEnvFile, lastSource(svc), "", name, "env file '%s' of service '%s' does not exist", envFile, name)
+			}
+		}
+	}
+}
+
+// checkUnreferencedSlices flags slice files no profile uses, and slices
+// listed under slices that no profile uses
+func (l *linter) checkUnreferencedSlices() {
+	if l.cfg == nil || len(l.cfg.Profiles) == 0 {
+		return
+	}
+
+	used := map[string]bool{}
+	for _, profile := range l.cfg.Profiles {
+		for _, slice := range profile.Slices {
+			if strings.HasPrefix(slice, "!") {
+				continue
+			}
+			used[slice] = true
+		}
+	}
+
+	// Files pulled in by the listed slices that profiles use
+	usedFiles := map[string]bool{}
+	listed := make([]string, 0, len(l.cfg.Slices))
+	for name := range l.cfg.Slices {
+		listed = append(listed, name)
+	}
+	sort.Strings(listed)
+	for _, name := range listed {
+		if !used[name] {
+			l.add(RuleUnreferencedSlice, l.cfg.Path, "", "", "slice '%s' listed under slices is not used by any profile", name)
+			continue
+		}
+		files, _ := l.cfg.SliceFiles(name)
+		for _, file := range files {
+			usedFiles[file] = true
+		}
+	}
+
+	names := make([]string, 0, len(l.discovery.Slices))
+	for name := range l.discovery.Slices {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		file := l.discovery.Slices[name]
+		_, redefined := l.cfg.Slices[name]
+		switch {
+		case usedFiles[file]:
+		case redefined && used[name]:
+			l.add(RuleUnreferencedSlice, file, "", "", "%s is not used by any profile; slices redefines '%s'", filepath.Base(file), name)
+		case !used[name]:
+			l.add(RuleUnreferencedSlice, file, "", "", "slice '%s' is not used by any profile", name)
+		}
+	}
+}
+
+// checkExtensionTwins flags compose files present as both .yml and .yaml
+func (l *linter) checkExtensionTwins() {
+	entries, err := os.ReadDir(l.dir)
+	if err != nil {
+		return
+	}
+	names := map[string]bool{}
+	for _, entry := range entries {
+		names[entry.Name()] = true
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasSuffix(name, ".yml") || !(strings.HasPrefix(name, "compose") || strings.HasPrefix(name, "docker-compose")) {
+			continue
+		}
+		twin := strings.TrimSuffix(name, ".yml") + ".yaml"
+		if names[twin] {
+			l.add(RuleExtensionTwins, filepath.Join(l.dir, name), "", "", "both %s and %s exist; dox uses %s", name, twin, twin)
+		}
+	}
+}
+
+// add records a finding unless the rule is off or the finding was already reported
+func (l *linter) add(rule, file, profile, service, format string, args ...any) {
+	severity := l.severity[rule]
+	if severity == SeverityOff {
+		return
+	}
+	finding := Finding{
+		Rule:     rule,
+		Severity: severity,
+		Message:  fmt.Sprintf(format, args...),
+		File:     l.rel(file),
+		Profile:  profile,
+		Service:  service,
+	}
+	key := finding.Rule + "|" + finding.File + "|" + finding.Message
+	if l.seen[key] {
+		return
+	}
+	l.seen[key] = true
+	l.report.Findings = append(l.report.Findings, finding)
+}
+
+func (l *linter) configFile() string {
+	if l.cfg != nil && l.cfg.Path != "" {
+		return l.cfg.Path
+	}
+	return filepath.Join(l.dir, "dox.yaml")
+}
+
+func (l *linter) inProfile(p profileFiles) string {
+	if p.Name == "" {
+		return ""
+	}
+	return fmt.Sprintf(" in profile '%s'", p.Name)
+}
+
+func (l *linter) exists(path string) bool {
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(l.dir, path)
+	}
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// rel returns path relative to the linted directory when possible
+func (l *linter) rel(path string) string {
+	if rel, err := filepath.Rel(l.dir, path); err == nil && !strings.HasPrefix(rel, "..") {
+		return rel
+	}
+	return path
+}
+
+func (l *linter) relAll(paths []string) []string {
+	rel := make([]string, len(paths))
+	for i, path := range paths {
+		rel[i] = l.rel(path)
+	}
+	return rel
+}
+
+func lastSource(svc *model.Service) string {
+	if len(svc.Sources) == 0 {
+		return ""
+	}
+	return svc.Sources[len(svc.Sources)-1]
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Counts returns the number of error and warning findings
+func (r *Report) Counts() (errors, warnings int) {
+	for _, f := range r.Findings {
+		if f.Severity == SeverityError {
+			errors++
+		} else {
+			warnings++
+		}
+	}
+	return errors, warnings
+}
+
+// Write renders the report in the given format
+func (r *Report) Write(w io.Writer, format string) error {
+	switch format {
+	case "", FormatText:
+		r.writeText(w)
+		return nil
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(r)
+	case FormatSARIF:
+		return r.writeSARIF(w)
+	default:
+		return fmt.Errorf("unsupported lint format '%s' (expected text, json or sarif)", format)
+	}
+}
+
+func (r *Report) writeText(w io.Writer) {
+	if len(r.Findings) == 0 {
+		fmt.Fprintln(w, "No problems found")
+		return
+	}
+	for _, f := range r.Findings {
+		fmt.Fprintf(w, "%s: %s [%s] %s\n", f.File, f.Severity, f.Rule, f.Message)
+	}
+	errors, warnings := r.Counts()
+	fmt.Fprintf(w, "\n%d errors, %d warnings\n", errors, warnings)
+}
@@ -0,0 +1,282 @@
+package lint
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/AkaraChen/dox/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+}
+
+// lintProject writes the given files to a temp dir and lints it
+func lintProject(t *testing.T, files map[string]string) *Report {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		writeFile(t, dir, name, content)
+	}
+	cfg, _, err := config.LoadConfigFromDirectory(dir)
+	require.NoError(t, err)
+
+	report, err := Run(dir, cfg)
+	require.NoError(t, err)
+	return report
+}
+
+func findingsFor(report *Report, rule string) []Finding {
+	var findings []Finding
+	for _, f := range report.Findings {
+		if f.Rule == rule {
+			findings = append(findings, f)
+		}
+	}
+	return findings
+}
+
+const lintCompose = `
+services:
+  web:
+    image: nginx:alpine
+    ports: ["8080:80"]
+  api:
+    image: example/api
+    depends_on: [db]
+`
+
+func TestRun_Rules(t *testing.T) {
+	report := lintProject(t, map[string]string{
+		"compose.yaml": lintCompose,
+		"compose.db.yaml": `
+services:
+  db:
+    image: postgres:16
+`,
+		"compose.dev.yaml": `
+services:
+  web:
+    image: nginx:latest
+  admin:
+    image: example/admin
+    ports: ["8080:3000"]
+    env_file: [admin.env]
+`,
+		"compose.dev.yml":     "services: {}\n",
+		"compose.unused.yaml": "services: {}\n",
+		"dox.yaml": `
+version: 1
+profiles:
+  dev:
+    slices: [dev]
+    env_file: .env.dev
+  full:
+    slices: [db]
+  broken:
+    slices: [missing]
+`,
+	})
+
+	image := findingsFor(report, RuleConflictingImage)
+	require.Len(t, image, 1)
+	assert.Equal(t, "service 'web' sets different images: nginx:alpine (compose.yaml), nginx:latest (compose.dev.yaml)", image[0].Message)
+	assert.Equal(t, SeverityWarning, image[0].Severity)
+
+	ports := findingsFor(report, RulePortCollision)
+	require.Len(t, ports, 1)
+	assert.Equal(t, "host port 8080/tcp is published by admin and web in profile 'dev'", ports[0].Message)
+	assert.Equal(t, "compose.dev.yaml", ports[0].File)
+
+	deps := findingsFor(report, RuleMissingDependency)
+	require.Len(t, deps, 1)
+	assert.Equal(t, "dev", deps[0].Profile)
+	assert.Equal(t, "service 'api' depends on 'db', which is not defined in profile 'dev'", deps[0].Message)
+
+	unresolved := findingsFor(report, RuleUnresolvedProfile)
+	require.Len(t, unresolved, 1)
+	assert.Equal(t, "broken", unresolved[0].Profile)
+	assert.Equal(t, "dox.yaml", unresolved[0].File)
+
+	unused := findingsFor(report, RuleUnreferencedSlice)
+	require.Len(t, unused, 1)
+	assert.Equal(t, "slice 'unused' is not used by any profile", unused[0].Message)
+
+	twins := findingsFor(report, RuleExtensionTwins)
+	require.Len(t, twins, 1)
+	assert.Equal(t, "compose.dev.yml", twins[0].File)
+
+	envFiles := findingsFor(report, RuleMissingEnvFile)
+	require.Len(t, envFiles, 2)
+	assert.Equal(t, "env file '.env.dev' of profile 'dev' does not exist", envFiles[0].Message)
+	assert.Equal(t, "env file 'admin.env' of service 'admin' does not exist", envFiles[1].Message)
+
+	errors, warnings := report.Counts()
+	assert.Equal(t, 5, errors)
+	assert.Equal(t, 3, warnings)
+}
+
+func TestRun_ListedSlices(t *testing.T) {
+	report := lintProject(t, map[string]string{
+		"compose.yaml":            "services: {}\n",
+		"compose.db.yaml":         "services: {}\n",
+		"compose.dev.yaml":        "services: {}\n",
+		"compose.monitoring.yaml": "services: {}\n",
+		"grafana.yaml":            "services: {}\n",
+		"dox.yaml": `
+version: 2
+slices:
+  dev: [grafana.yaml]
+  full: [compose.db.yaml, compose.monitoring.yaml]
+  spare: [grafana.yaml]
+profiles:
+  dev:
+    slices: [dev, full]
+`,
+	})
+
+	unused := findingsFor(report, RuleUnreferencedSlice)
+	require.Len(t, unused, 2)
+	assert.Equal(t, "dox.yaml", unused[0].File)
+	assert.Equal(t, "slice 'spare' listed under slices is not used by any profile", unused[0].Message)
+	assert.Equal(t, "compose.dev.yaml", unused[1].File)
+	assert.Equal(t, "compose.dev.yaml is not used by any profile; slices redefines 'dev'", unused[1].Message)
+}
+
+func TestRun_ConflictingImagesWithinProfiles(t *testing.T) {
+	report := lintProject(t, map[string]string{
+		"compose.yaml":       "services:\n  web:\n    build: .\n",
+		"compose.blue.yaml":  "services:\n  web:\n    image: shop:blue\n",
+		"compose.green.yaml": "services:\n  web:\n    image: shop:green\n",
+		"deploy/canary.yaml": "services:\n  web:\n    image: shop:canary\n",
+		"dox.yaml": `
+version: 2
+slices:
+  canary: [deploy/canary.yaml]
+profiles:
+  blue:
+    slices: [blue]
+  green:
+    slices: [green]
+  canary:
+    slices: [green, canary]
+`,
+	})
+
+	image := findingsFor(report, RuleConflictingImage)
+	require.Len(t, image, 1, "blue and green are never combined")
+	assert.Equal(t, "service 'web' sets different images: shop:canary (deploy/canary.yaml), shop:green (compose.green.yaml)", image[0].Message)
+}
+
+func TestRun_PortCollisionAllInterfaces(t *testing.T) {
+	report := lintProject(t, map[string]string{
+		"compose.yaml": `
+services:
+  web:
+    image: nginx
+    ports: ["0.0.0.0:8080:80"]
+  admin:
+    image: example/admin
+    ports: ["8080:3000"]
+  docs:
+    image: example/docs
+    ports: ["[::]:8080:4000", "127.0.0.1:9000:9000"]
+  metrics:
+    image: example/metrics
+    ports: ["127.0.0.2:9000:9000"]
+`,
+	})
+
+	ports := findingsFor(report, RulePortCollision)
+	require.Len(t, ports, 1)
+	assert.Equal(t, "host port 8080/tcp is published by admin and docs and web", ports[0].Message)
+}
+
+func TestRun_ConfiguredSeverity(t *testing.T) {
+	report := lintProject(t, map[string]string{
+		"compose.yaml": lintCompose,
+		"dox.yaml": `
+version: 1
+lint:
+  rules:
+    missing-dependency: warning
+`,
+	})
+
+	require.Len(t, report.Findings, 1)
+	assert.Equal(t, SeverityWarning, report.Findings[0].Severity)
+
+	report = lintProject(t, map[string]string{
+		"compose.yaml": lintCompose,
+		"dox.yaml": `
+version: 1
+lint:
+  rules:
+    missing-dependency: "off"
+`,
+	})
+	assert.Empty(t, report.Findings)
+}
+
+func TestRun_InvalidRuleConfig(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "compose.yaml", lintCompose)
+
+	_, err := Run(dir, &config.Config{Lint: config.LintConfig{Rules: map[string]string{"no-such-rule": "error"}}})
+	assert.ErrorContains(t, err, "unknown lint rule 'no-such-rule'")
+
+	_, err = Run(dir, &config.Config{Lint: config.LintConfig{Rules: map[string]string{RulePortCollision: "fatal"}}})
+	assert.ErrorContains(t, err, "invalid severity 'fatal'")
+}
+
+func TestRun_Fixtures(t *testing.T) {
+	report, err := Run(filepath.Join("..", "..", "test", "fixtures", "with-env"), nil)
+	require.NoError(t, err)
+	assert.Empty(t, report.Findings)
+
+	report, err = Run(filepath.Join("..", "..", "test", "fixtures", "duplicate-services"), nil)
+	require.NoError(t, err)
+	assert.Empty(t, findingsFor(report, RuleConflictingImage))
+}
+
+func TestReport_Write(t *testing.T) {
+	report := &Report{Findings: []Finding{
+		{Rule: RulePortCollision, Severity: SeverityError, Message: "host port 80/tcp is published by a and b", File: "compose.yaml"},
+		{Rule: RuleUnreferencedSlice, Severity: SeverityWarning, Message: "slice 'x' is not used by any profile", File: "compose.x.yaml"},
+	}}
+
+	var text bytes.Buffer
+	require.NoError(t, report.Write(&text, FormatText))
+	assert.Equal(t, `compose.yaml: error [port-collision] host port 80/tcp is published by a and b
+compose.x.yaml: warning [unreferenced-slice] slice 'x' is not used by any profile
+
+1 errors, 1 warnings
+`, text.String())
+
+	var sarif bytes.Buffer
+	require.NoError(t, report.Write(&sarif, FormatSARIF))
+	var log sarifLog
+	require.NoError(t, json.Unmarshal(sarif.Bytes(), &log))
+	assert.Equal(t, "2.1.0", log.Version)
+	require.Len(t, log.Runs, 1)
+	assert.Equal(t, "dox", log.Runs[0].Tool.Driver.Name)
+	assert.Len(t, log.Runs[0].Tool.Driver.Rules, len(Rules))
+	require.Len(t, log.Runs[0].Results, 2)
+	assert.Equal(t, "port-collision", log.Runs[0].Results[0].RuleID)
+	assert.Equal(t, "error", log.Runs[0].Results[0].Level)
+	assert.Equal(t, "compose.yaml", log.Runs[0].Results[0].Locations[0].PhysicalLocation.ArtifactLocation.URI)
+
+	var empty bytes.Buffer
+	require.NoError(t, (&Report{}).Write(&empty, FormatText))
+	assert.Equal(t, "No problems found\n", empty.String())
+
+	assert.Error(t, report.Write(&empty, "xml"))
+}
@@ -0,0 +1,191 @@
+package model
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// decodeService converts a merged raw service definition into a Service
+func decodeService(name string, raw map[string]any) *Service {
+	svc := &Service{
+		Name:        name,
+		Image:       scalar(raw["image"]),
+		Networks:    sortedKeys(namedMapping(raw["networks"])),
+		Profiles:    stringList(raw["profiles"]),
+		Environment: map[string]string{},
+		DependsOn:   map[string]Dependency{},
+		Raw:         raw,
+	}
+
+	if build, ok := buildMapping(raw["build"]).(map[string]any); ok {
+		svc.Build = &Build{Context: scalar(build["context"]), Dockerfile: scalar(build["dockerfile"])}
+	}
+
+	if ports, ok := raw["ports"].([]any); ok {
+		for _, item := range ports {
+			// compose reports invalid ports itself; keep them as written
+			port, err := parsePort(item)
+			if err != nil {
+				port = Port{Spec: scalar(item)}
+			}
+			svc.Ports = append(svc.Ports, port)
+		}
+	}
+
+	if volumes, ok := raw["volumes"].([]any); ok {
+		for _, item := range volumes {
+			svc.Volumes = append(svc.Volumes, parseMount(item))
+		}
+	}
+
+	for dep, value := range dependsOnMapping(raw["depends_on"]) {
+		condition := ConditionStarted
+		if m, ok := value.(map[string]any); ok && m["condition"] != nil {
+			condition = scalar(m["condition"])
+		}
+		svc.DependsOn[dep] = Dependency{Condition: condition}
+	}
+
+	for key, value := range toMapping(raw["environment"]) {
+		svc.Environment[key] = scalar(value)
+	}
+
+	switch envFiles := raw["env_file"].(type) {
+	case string:
+		svc.EnvFiles = []string{envFiles}
+	case []any:
+		for _, item := range envFiles {
+			if m, ok := item.(map[string]any); ok {
+				svc.EnvFiles = append(svc.EnvFiles, scalar(m["path"]))
+			} else {
+				svc.EnvFiles = append(svc.EnvFiles, scalar(item))
+			}
+		}
+	}
+
+	if hc, ok := raw["healthcheck"].(map[string]any); ok {
+		svc.Healthcheck = decodeHealthcheck(hc)
+	}
+
+	return svc
+}
+
+func decodeHealthcheck(hc map[string]any) *Healthcheck {
+	h := &Healthcheck{
+		Interval:    scalar(hc["interval"]),
+		Timeout:     scalar(hc["timeout"]),
+		StartPeriod: scalar(hc["start_period"]),
+	}
+	switch test := hc["test"].(type) {
+	case string:
+		h.Test = []string{"CMD-SHELL", test}
+	case []any:
+		h.Test = stringList(test)
+	}
+	if retries, err := strconv.Atoi(scalar(hc["retries"])); err == nil {
+		h.Retries = retries
+	}
+	h.Disable, _ = hc["disable"].(bool)
+	if len(h.Test) == 1 && h.Test[0] == "NONE" {
+		h.Disable = true
+	}
+	return h
+}
+
+// parsePort parses the short ("[ip:][published:]target[/proto]") or long port
+// syntax. An IPv6 ip is written in brackets. A short spec with a variable is
+// only known after interpolation, so it is kept as written in Spec.
+func parsePort(value any) (Port, error) {
+	if m, ok := value.(map[string]any); ok {
+		return Port{
+			HostIP:    scalar(m["host_ip"]),
+			Published: scalar(m["published"]),
+			Target:    scalar(m["target"]),
+			Protocol:  scalar(m["protocol"]),
+		}, nil
+	}
+
+	spec := scalar(value)
+	if strings.Contains(spec, "$") {
+		return Port{Spec: spec}, nil
+	}
+	var port Port
+	spec, port.Protocol, _ = strings.Cut(spec, "/")
+	if strings.HasPrefix(spec, "[") {
+		ip, rest, ok := strings.Cut(spec[1:], "]:")
+		if !ok {
+			return Port{}, fmt.Errorf("invalid port '%s'", scalar(value))
+		}
+		port.HostIP, spec = ip, rest
+		parts := strings.Split(spec, ":")
+		if len(parts) != 2 {
+			return Port{}, fmt.Errorf("invalid port '%s'", scalar(value))
+		}
+		port.Published, port.Target = parts[0], parts[1]
+		return port, nil
+	}
+	parts := strings.Split(spec, ":")
+	switch len(parts) {
+	case 1:
+		port.Target = parts[0]
+	case 2:
+		port.Published, port.Target = parts[0], parts[1]
+	case 3:
+		port.HostIP, port.Published, port.Target = parts[0], parts[1], parts[2]
+	default:
+		return Port{}, fmt.Errorf("invalid port '%s'", scalar(value))
+	}
+	return port, nil
+}
+
+// parseMount parses the short ("[source:]target[:mode]") or long volume syntax
+func parseMount(value any) Mount {
+	if m, ok := value.(map[string]any); ok {
+		mount := Mount{
+			Type:   scalar(m["type"]),
+			Source: scalar(m["source"]),
+			Target: scalar(m["target"]),
+		}
+		mount.ReadOnly, _ = m["read_only"].(bool)
+		return mount
+	}
+
+	parts := strings.Split(scalar(value), ":")
+	if len(parts) == 1 {
+		return Mount{Type: MountVolume, Target: parts[0]}
+	}
+
+	mount := Mount{Source: parts[0], Target: parts[1], Type: MountVolume}
+	if len(parts) > 2 {
+		for _, opt := range strings.Split(parts[2], ",") {
+			if opt == "ro" {
+				mount.ReadOnly = true
+			}
+		}
+	}
+	if strings.HasPrefix(mount.Source, ".") || strings.HasPrefix(mount.Source, "/") || strings.HasPrefix(mount.Source, "~") {
+		mount.Type = MountBind
+	}
+	return mount
+}
+
+// decodeResource converts a top-level network or volume definition
+func decodeResource(name string, raw any) *Resource {
+	res := &Resource{Name: name}
+	m, ok := raw.(map[string]any)
+	if !ok {
+		return res
+	}
+	if n := scalar(m["name"]); n != "" {
+		res.Name = n
+	}
+	res.Driver = scalar(m["driver"])
+	switch ext := m["external"].(type) {
+	case bool:
+		res.External = ext
+	case map[string]any:
+		res.External = true
+	}
+	return res
+}
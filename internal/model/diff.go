@@ -0,0 +1,232 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/AkaraChen/dox/internal/ansi"
+)
+
+// FieldChange is a single difference in a service. Old is nil for added
+// values and New is nil for removed ones; a pointer to "" is a value set
+// to an empty string.
+type FieldChange struct {
+	Field string  `json:"field"`
+	Old   *string `json:"old,omitempty"`
+	New   *string `json:"new,omitempty"`
+}
+
+// ServiceDiff lists the differences of a service present in both projects
+type ServiceDiff struct {
+	Name    string        `json:"name"`
+	Changes []FieldChange `json:"changes"`
+}
+
+// ProjectDiff is the semantic difference between two compose projects
+type ProjectDiff struct {
+	From       string        `json:"from"`
+	To         string        `json:"to"`
+	OnlyInFrom []string      `json:"only_in_from"`
+	OnlyInTo   []string      `json:"only_in_to"`
+	Services   []ServiceDiff `json:"services"`
+}
+
+// DiffProjects compares the services of two projects field by field:
+// image, command, entrypoint, replicas, environment, ports and volumes.
+// from and to name the two sides in the output.
+func DiffProjects(from, to string, a, b *Project) *ProjectDiff {
+	diff := &ProjectDiff{
+		From:       from,
+		To:         to,
+		OnlyInFrom: []string{},
+		OnlyInTo:   []string{},
+		Services:   []ServiceDiff{},
+	}
+
+	for _, name := range a.ServiceNames() {
+		if _, ok := b.Services[name]; !ok {
+			diff.OnlyInFrom = append(diff.OnlyInFrom, name)
+		}
+	}
+	for _, name := range b.ServiceNames() {
+		old, ok := a.Services[name]
+		if !ok {
+			diff.OnlyInTo = append(diff.OnlyInTo, name)
+			continue
+		}
+		if changes := diffService(old, b.Services[name]); len(changes) > 0 {
+			diff.Services = append(diff.Services, ServiceDiff{Name: name, Changes: changes})
+		}
+	}
+	return diff
+}
+
+// Empty reports whether the projects have no differences
+func (d *ProjectDiff) Empty() bool {
+	return len(d.OnlyInFrom) == 0 && len(d.OnlyInTo) == 0 && len(d.Services) == 0
+}
+
+func diffService(a, b *Service) []FieldChange {
+	var changes []FieldChange
+	scalars := []struct {
+		field         string
+		before, after *string
+	}{
+		{"image", formatValue(a.Raw["image"]), formatValue(b.Raw["image"])},
+		{"command", formatValue(a.Raw["command"]), formatValue(b.Raw["command"])},
+		{"entrypoint", formatValue(a.Raw["entrypoint"]), formatValue(b.Raw["entrypoint"])},
+		{"replicas", replicas(a), replicas(b)},
+	}
+	for _, s := range scalars {
+		if !equalValues(s.before, s.after) {
+			changes = append(changes, FieldChange{Field: s.field, Old: s.before, New: s.after})
+		}
+	}
+
+	keys := map[string]bool{}
+	for key := range a.Environment {
+		keys[key] = true
+	}
+	for key := range b.Environment {
+		keys[key] = true
+	}
+	for _, key := range sortedSet(keys) {
+		before, after := envValue(a, key), envValue(b, key)
+		if !equalValues(before, after) {
+			changes = append(changes, FieldChange{Field: "environment." + key, Old: before, New: after})
+		}
+	}
+
+	var oldPorts, newPorts []string
+	for _, port := range a.Ports {
+		oldPorts = append(oldPorts, port.String())
+	}
+	for _, port := range b.Ports {
+		newPorts = append(newPorts, port.String())
+	}
+	changes = append(changes, diffSet("ports", oldPorts, newPorts)...)
+
+	var oldVolumes, newVolumes []string
+	for _, mount := range a.Volumes {
+		oldVolumes = append(oldVolumes, mount.String())
+	}
+	for _, mount := range b.Volumes {
+		newVolumes = append(newVolumes, mount.String())
+	}
+	changes = append(changes, diffSet("volumes", oldVolumes, newVolumes)...)
+
+	return changes
+}
+
+// diffSet reports entries removed from and added to an unordered list
+func diffSet(field string, before, after []string) []FieldChange {
+	inBefore := map[string]bool{}
+	for _, v := range before {
+		inBefore[v] = true
+	}
+	inAfter := map[string]bool{}
+	for _, v := range after {
+		inAfter[v] = true
+	}
+
+	var changes []FieldChange
+	for _, v := range before {
+		if !inAfter[v] {
+			changes = append(changes, FieldChange{Field: field, Old: &v})
+		}
+	}
+	for _, v := range after {
+		if !inBefore[v] {
+			changes = append(changes, FieldChange{Field: field, New: &v})
+		}
+	}
+	return changes
+}
+
+// equalValues reports whether two optional values are both unset or equal
+func equalValues(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// envValue returns an environment variable of a service, nil when unset
+func envValue(s *Service, key string) *string {
+	value, ok := s.Environment[key]
+	if !ok {
+		return nil
+	}
+	return &value
+}
+
+// replicas returns deploy.replicas or the legacy scale, nil when unset
+func replicas(s *Service) *string {
+	if deploy, ok := s.Raw["deploy"].(map[string]any); ok && deploy["replicas"] != nil {
+		return formatValue(deploy["replicas"])
+	}
+	return formatValue(s.Raw["scale"])
+}
+
+// formatCommand formats a value given as a scalar or a list, nil when unset
+func formatValue(value any) *string {
+	if value == nil {
+		return nil
+	}
+	formatted := scalar(value)
+	if list, ok := value.([]any); ok {
+		formatted = strings.Join(stringList(list), " ")
+	}
+	return &formatted
+}
+
+// WriteText writes the diff for humans, with ANSI colors when color is set
+func (d *ProjectDiff) WriteText(w io.Writer, color bool) {
+	paint := func(code, s string) string { return ansi.Paint(color, code, s) }
+
+	if d.Empty() {
+		fmt.Fprintf(w, "No differences between %s and %s\n", d.From, d.To)
+		return
+	}
+
+	if len(d.OnlyInFrom) > 0 {
+		fmt.Fprintln(w, paint(ansi.Red, fmt.Sprintf("Only in %s: %s", d.From, strings.Join(d.OnlyInFrom, ", "))))
+	}
+	if len(d.OnlyInTo) > 0 {
+		fmt.Fprintln(w, paint(ansi.Green, fmt.Sprintf("Only in %s: %s", d.To, strings.Join(d.OnlyInTo, ", "))))
+	}
+
+	for i, svc := range d.Services {
+		if i > 0 || len(d.OnlyInFrom)+len(d.OnlyInTo) > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintln(w, paint(ansi.Bold, svc.Name))
+		for _, c := range svc.Changes {
+			switch {
+			case c.Old == nil:
+				fmt.Fprintln(w, paint(ansi.Green, fmt.Sprintf("  + %s: %s", c.Field, textValue(*c.New))))
+			case c.New == nil:
+				fmt.Fprintln(w, paint(ansi.Red, fmt.Sprintf("  - %s: %s", c.Field, textValue(*c.Old))))
+			default:
+				fmt.Fprintln(w, paint(ansi.Yellow, fmt.Sprintf("  ~ %s: %s -> %s", c.Field, textValue(*c.Old), textValue(*c.New))))
+			}
+		}
+	}
+}
+
+// textValue shows an empty value as "" so it is not mistaken for a removal
+func textValue(s string) string {
+	if s == "" {
+		return `""`
+	}
+	return s
+}
+
+// WriteJSON writes the diff as indented JSON
+func (d *ProjectDiff) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(d)
+}
@@ -0,0 +1,143 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/AkaraChen/dox/internal/ansi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func value(s string) *string {
+	return &s
+}
+
+func TestDiffProjects(t *testing.T) {
+	dir := t.TempDir()
+	staging, err := Load(writeFile(t, dir, "staging.yaml", `
+services:
+  api:
+    image: example/api:1.4
+    command: ["serve", "--debug"]
+    environment:
+      LOG_LEVEL: debug
+      DEBUG: "1"
+    ports: ["8080:80"]
+    volumes: ["./src:/app"]
+  mailhog:
+    image: mailhog/mailhog
+`))
+	require.NoError(t, err)
+	prod, err := Load(writeFile(t, dir, "prod.yaml", `
+services:
+  api:
+    image: example/api:1.5
+    command: ["serve"]
+    environment:
+      LOG_LEVEL: info
+      SENTRY_DSN: https://sentry
+    ports: ["80:80"]
+    deploy:
+      replicas: 3
+  cdn:
+    image: nginx
+`))
+	require.NoError(t, err)
+
+	diff := DiffProjects("staging", "prod", staging, prod)
+	assert.Equal(t, []string{"mailhog"}, diff.OnlyInFrom)
+	assert.Equal(t, []string{"cdn"}, diff.OnlyInTo)
+	require.Len(t, diff.Services, 1)
+	assert.Equal(t, []FieldChange{
+		{Field: "image", Old: value("example/api:1.4"), New: value("example/api:1.5")},
+		{Field: "command", Old: value("serve --debug"), New: value("serve")},
+		{Field: "replicas", New: value("3")},
+		{Field: "environment.DEBUG", Old: value("1")},
+		{Field: "environment.LOG_LEVEL", Old: value("debug"), New: value("info")},
+		{Field: "environment.SENTRY_DSN", New: value("https://sentry")},
+		{Field: "ports", Old: value("8080:80")},
+		{Field: "ports", New: value("80:80")},
+		{Field: "volumes", Old: value("./src:/app")},
+	}, diff.Services[0].Changes)
+
+	var text bytes.Buffer
+	diff.WriteText(&text, false)
+	assert.Equal(t, `Only in staging: mailhog
+Only in prod: cdn
+
+api
+  ~ image: example/api:1.4 -> example/api:1.5
+  ~ command: serve --debug -> serve
+  + replicas: 3
+  - environment.DEBUG: 1
+  ~ environment.LOG_LEVEL: debug -> info
+  + environment.SENTRY_DSN: https://sentry
+  - ports: 8080:80
+  + ports: 80:80
+  - volumes: ./src:/app
+`, text.String())
+
+	var colored bytes.Buffer
+	diff.WriteText(&colored, true)
+	assert.Contains(t, colored.String(), ansi.Green+"  + replicas: 3"+ansi.Reset)
+
+	var out bytes.Buffer
+	require.NoError(t, diff.WriteJSON(&out))
+	var decoded ProjectDiff
+	require.NoError(t, json.Unmarshal(out.Bytes(), &decoded))
+	assert.Equal(t, *diff, decoded)
+}
+
+func TestDiffProjects_Identical(t *testing.T) {
+	project, err := Load(writeFile(t, t.TempDir(), "compose.yaml", "services:\n  web:\n    image: nginx\n"))
+	require.NoError(t, err)
+
+	diff := DiffProjects("a", "b", project, project)
+	assert.True(t, diff.Empty())
+
+	var text bytes.Buffer
+	diff.WriteText(&text, false)
+	assert.Equal(t, "No differences between a and b\n", text.String())
+}
+
+func TestDiffProjects_EmptyValues(t *testing.T) {
+	dir := t.TempDir()
+	before, err := Load(writeFile(t, dir, "before.yaml", `
+services:
+  api:
+    image: example/api
+    environment:
+      TOKEN: secret
+      EMPTY: ""
+`))
+	require.NoError(t, err)
+	after, err := Load(writeFile(t, dir, "after.yaml", `
+services:
+  api:
+    build: .
+    environment:
+      TOKEN: ""
+      FRESH: ""
+`))
+	require.NoError(t, err)
+
+	diff := DiffProjects("before", "after", before, after)
+	require.Len(t, diff.Services, 1)
+	assert.Equal(t, []FieldChange{
+		{Field: "image", Old: value("example/api")},
+		{Field: "environment.EMPTY", Old: value("")},
+		{Field: "environment.FRESH", New: value("")},
+		{Field: "environment.TOKEN", Old: value("secret"), New: value("")},
+	}, diff.Services[0].Changes)
+
+	var text bytes.Buffer
+	diff.WriteText(&text, false)
+	assert.Equal(t, `api
+  - image: example/api
+  - environment.EMPTY: ""
+  + environment.FRESH: ""
+  ~ environment.TOKEN: secret -> ""
+`, text.String())
+}
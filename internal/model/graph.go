@@ -0,0 +1,388 @@
+package model
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Node kinds in a Graph
+const (
+	NodeService = "service"
+	NodeNetwork = "network"
+	NodeVolume  = "volume"
+)
+
+// Edge kinds in a Graph
+const (
+	EdgeDependsOn = "depends_on"
+	EdgeNetwork   = "network"
+	EdgeVolume    = "volume"
+)
+
+// Graph formats supported by Graph.Write
+const (
+	GraphText    = "text"
+	GraphDOT     = "dot"
+	GraphMermaid = "mermaid"
+)
+
+// Change marks what a compared profile adds, removes or changes
+type Change string
+
+const (
+	Unchanged Change = ""
+	Added     Change = "added"
+	Removed   Change = "removed"
+	Changed   Change = "changed"
+)
+
+// Node is a service, network or named volume in the topology
+type Node struct {
+	Kind   string
+	Name   string
+	Ports  []string
+	Change Change
+}
+
+// ID returns the unique identifier of the node
+func (n Node) ID() string {
+	return n.Kind + ":" + n.Name
+}
+
+// Edge connects a service to another service, a network or a volume.
+// Label holds the depends_on condition or the volume mount target.
+type Edge struct {
+	Kind   string
+	From   string
+	To     string
+	Label  string
+	Change Change
+}
+
+func (e Edge) key() string {
+	return strings.Join([]string{e.Kind, e.From, e.To, e.Label}, "|")
+}
+
+// Graph is the topology of a compose project
+type Graph struct {
+	Nodes []Node
+	Edges []Edge
+}
+
+// NewGraph builds the topology of a project: services with their published
+// ports, depends_on edges, explicitly joined networks and named volumes
+func NewGraph(p *Project) *Graph {
+	g := &Graph{}
+	networks := map[string]bool{}
+	volumes := map[string]bool{}
+
+	for _, name := range p.ServiceNames() {
+		svc := p.Services[name]
+		service := Node{Kind: NodeService, Name: name}
+		for _, port := range svc.Ports {
+			if port.Published != "" || port.Spec != "" {
+				service.Ports = append(service.Ports, port.String())
+			}
+		}
+		g.Nodes = append(g.Nodes, service)
+
+		deps := make([]string, 0, len(svc.DependsOn))
+		for dep := range svc.DependsOn {
+			deps = append(deps, dep)
+		}
+		sort.Strings(deps)
+		for _, dep := range deps {
+			g.Edges = append(g.Edges, Edge{Kind: EdgeDependsOn, From: service.ID(), To: NodeService + ":" + dep, Label: svc.DependsOn[dep].Condition})
+		}
+
+		for _, network := range svc.Networks {
+			networks[network] = true
+			g.Edges = append(g.Edges, Edge{Kind: EdgeNetwork, From: service.ID(), To: NodeNetwork + ":" + network})
+		}
+
+		for _, mount := range svc.Volumes {
+			if mount.Type != MountVolume || mount.Source == "" {
+				continue
+			}
+			volumes[mount.Source] = true
+			g.Edges = append(g.Edges, Edge{Kind: EdgeVolume, From: service.ID(), To: NodeVolume + ":" + mount.Source, Label: mount.Target})
+		}
+	}
+
+	for _, name := range sortedSet(networks) {
+		g.Nodes = append(g.Nodes, Node{Kind: NodeNetwork, Name: name})
+	}
+	for _, name := range sortedSet(volumes) {
+		g.Nodes = append(g.Nodes, Node{Kind: NodeVolume, Name: name})
+	}
+	return g
+}
+
+// Diff returns the union of g and other, marking nodes and edges that only
+// other has as added, those only g has as removed, and services whose
+// published ports differ as changed
+func (g *Graph) Diff(other *Graph) *Graph {
+	result := &Graph{}
+
+	otherNodes := map[string]Node{}
+	for _, node := range other.Nodes {
+		otherNodes[node.ID()] = node
+	}
+	for _, node := range g.Nodes {
+		if o, ok := otherNodes[node.ID()]; ok {
+			if strings.Join(node.Ports, ",") != strings.Join(o.Ports, ",") {
+				o.Change = Changed
+			}
+			result.Nodes = append(result.Nodes, o)
+			delete(otherNodes, node.ID())
+		} else {
+			node.Change = Removed
+			result.Nodes = append(result.Nodes, node)
+		}
+	}
+	for _, node := range other.Nodes {
+		if _, ok := otherNodes[node.ID()]; ok {
+			node.Change = Added
+			result.Nodes = append(result.Nodes, node)
+		}
+	}
+
+	otherEdges := map[string]bool{}
+	for _, edge := range other.Edges {
+		otherEdges[edge.key()] = true
+	}
+	seen := map[string]bool{}
+	for _, edge := range g.Edges {
+		seen[edge.key()] = true
+		if !otherEdges[edge.key()] {
+			edge.Change = Removed
+		}
+		result.Edges = append(result.Edges, edge)
+	}
+	for _, edge := range other.Edges {
+		if !seen[edge.key()] {
+			edge.Change = Added
+			result.Edges = append(result.Edges, edge)
+		}
+	}
+
+	return result
+}
+
+// Write renders the graph in the given format
+func (g *Graph) Write(w io.Writer, format string) error {
+	switch format {
+	case "", GraphText:
+		g.writeText(w)
+	case GraphDOT:
+		g.writeDOT(w)
+	case GraphMermaid:
+		g.writeMermaid(w)
+	default:
+		return fmt.Errorf("unsupported graph format '%s' (expected text, dot or mermaid)", format)
+	}
+	return nil
+}
+
+// writeText renders each service as a tree of its dependencies, networks and
+// volumes, followed by the networks and volumes a compared profile adds or
+// removes
+func (g *Graph) writeText(w io.Writer) {
+	for _, node := range g.Nodes {
+		if node.Kind != NodeService {
+			continue
+		}
+		fmt.Fprintf(w, "%s%s%s\n", node.Name, formatPorts(node.Ports, " [", "]"), changeSuffix(node.Change))
+
+		edges := g.edgesFrom(node.ID())
+		for i, edge := range edges {
+			branch := "├── "
+			if i == len(edges)-1 {
+				branch = "└── "
+			}
+			_, target, _ := strings.Cut(edge.To, ":")
+			var line string
+			switch edge.Kind {
+			case EdgeDependsOn:
+				line = fmt.Sprintf("depends on %s (%s)", target, edge.Label)
+			case EdgeNetwork:
+				line = "network " + target
+			case EdgeVolume:
+				line = fmt.Sprintf("volume %s -> %s", target, edge.Label)
+			}
+			fmt.Fprintf(w, "%s%s%s\n", branch, line, changeSuffix(edge.Change))
+		}
+	}
+
+	for _, node := range g.Nodes {
+		if node.Kind != NodeService && node.Change != Unchanged {
+			fmt.Fprintf(w, "%s %s%s\n", node.Kind, node.Name, changeSuffix(node.Change))
+		}
+	}
+}
+
+// writeDOT renders the graph in Graphviz DOT
+func (g *Graph) writeDOT(w io.Writer) {
+	fmt.Fprintln(w, "digraph dox {")
+	fmt.Fprintln(w, "  rankdir=LR;")
+	for _, node := range g.Nodes {
+		attrs := []string{fmt.Sprintf("label=%q", node.Name+formatPorts(node.Ports, "\n", ""))}
+		style := ""
+		switch node.Kind {
+		case NodeService:
+			attrs = append(attrs, "shape=box")
+		case NodeNetwork:
+			attrs = append(attrs, "shape=ellipse")
+			style = "dashed"
+		case NodeVolume:
+			attrs = append(attrs, "shape=cylinder")
+		}
+		attrs = append(attrs, dotChangeAttrs(node.Change, style)...)
+		fmt.Fprintf(w, "  %q [%s];\n", node.ID(), strings.Join(attrs, ", "))
+	}
+	for _, edge := range g.Edges {
+		var attrs []string
+		style := ""
+		switch edge.Kind {
+		case EdgeDependsOn:
+			attrs = append(attrs, fmt.Sprintf("label=%q", edge.Label))
+		case EdgeNetwork:
+			attrs = append(attrs, "arrowhead=none")
+			style = "dashed"
+		case EdgeVolume:
+			attrs = append(attrs, fmt.Sprintf("label=%q", edge.Label), "arrowhead=none")
+		}
+		attrs = append(attrs, dotChangeAttrs(edge.Change, style)...)
+		fmt.Fprintf(w, "  %q -> %q [%s];\n", edge.From, edge.To, strings.Join(attrs, ", "))
+	}
+	fmt.Fprintln(w, "}")
+}
+
+// writeMermaid renders the graph as a Mermaid flowchart
+func (g *Graph) writeMermaid(w io.Writer) {
+	fmt.Fprintln(w, "graph LR")
+
+	// Nodes are numbered rather than named after escaped IDs, which could
+	// collide; edge ends without a node, like a missing dependency, get one
+	ids := map[string]string{}
+	nodes := append([]Node{}, g.Nodes...)
+	for _, node := range nodes {
+		ids[node.ID()] = ""
+	}
+	for _, edge := range g.Edges {
+		for _, end := range []string{edge.From, edge.To} {
+			if _, ok := ids[end]; !ok {
+				ids[end] = ""
+				kind, name, _ := strings.Cut(end, ":")
+				nodes = append(nodes, Node{Kind: kind, Name: name})
+			}
+		}
+	}
+	for i, node := range nodes {
+		ids[node.ID()] = fmt.Sprintf("n%d", i)
+	}
+
+	classes := map[Change][]string{}
+	for _, node := range nodes {
+		id := ids[node.ID()]
+		label := node.Name + formatPorts(node.Ports, "<br/>", "")
+		switch node.Kind {
+		case NodeService:
+			fmt.Fprintf(w, "  %s[\"%s\"]\n", id, label)
+		case NodeNetwork:
+			fmt.Fprintf(w, "  %s([\"%s\"])\n", id, label)
+		case NodeVolume:
+			fmt.Fprintf(w, "  %s[(\"%s\")]\n", id, label)
+		}
+		if node.Change != Unchanged {
+			classes[node.Change] = append(classes[node.Change], id)
+		}
+	}
+
+	var linkStyles []string
+	for i, edge := range g.Edges {
+		from, to := ids[edge.From], ids[edge.To]
+		switch edge.Kind {
+		case EdgeDependsOn:
+			fmt.Fprintf(w, "  %s -->|\"%s\"| %s\n", from, edge.Label, to)
+		case EdgeNetwork:
+			fmt.Fprintf(w, "  %s -.- %s\n", from, to)
+		case EdgeVolume:
+			fmt.Fprintf(w, "  %s ---|\"%s\"| %s\n", from, edge.Label, to)
+		}
+		if edge.Change != Unchanged {
+			linkStyles = append(linkStyles, fmt.Sprintf("  linkStyle %d stroke:%s", i, changeColor(edge.Change)))
+		}
+	}
+
+	for _, change := range []Change{Added, Removed, Changed} {
+		if ids := classes[change]; len(ids) > 0 {
+			fmt.Fprintf(w, "  classDef %s stroke:%s,color:%s\n", change, changeColor(change), changeColor(change))
+			fmt.Fprintf(w, "  class %s %s\n", strings.Join(ids, ","), change)
+		}
+	}
+	for _, style := range linkStyles {
+		fmt.Fprintln(w, style)
+	}
+}
+
+func (g *Graph) edgesFrom(id string) []Edge {
+	var edges []Edge
+	for _, edge := range g.Edges {
+		if edge.From == id {
+			edges = append(edges, edge)
+		}
+	}
+	return edges
+}
+
+func formatPorts(ports []string, prefix, suffix string) string {
+	if len(ports) == 0 {
+		return ""
+	}
+	return prefix + strings.Join(ports, ", ") + suffix
+}
+
+func changeSuffix(change Change) string {
+	if change == Unchanged {
+		return ""
+	}
+	return fmt.Sprintf(" (%s)", change)
+}
+
+func changeColor(change Change) string {
+	switch change {
+	case Added:
+		return "green"
+	case Removed:
+		return "red"
+	}
+	return "orange"
+}
+
+// dotChangeAttrs returns the color of a change and the style, which is
+// dotted for removed nodes and edges instead of the given one
+func dotChangeAttrs(change Change, style string) []string {
+	var attrs []string
+	if change != Unchanged {
+		attrs = append(attrs, "color="+changeColor(change), "fontcolor="+changeColor(change))
+	}
+	if change == Removed {
+		style = "dotted"
+	}
+	if style != "" {
+		attrs = append(attrs, "style="+style)
+	}
+	return attrs
+}
+
+func sortedSet(set map[string]bool) []string {
+	names := make([]string, 0, len(set))
+	for name := range set {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,124 @@
+package model
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func loadGraph(t *testing.T, files ...string) *Graph {
+	dir := setupFixture("compose-model")
+	paths := make([]string, len(files))
+	for i, file := range files {
+		paths[i] = filepath.Join(dir, file)
+	}
+	project, err := Load(paths...)
+	require.NoError(t, err)
+	return NewGraph(project)
+}
+
+func TestNewGraph(t *testing.T) {
+	g := loadGraph(t, "compose.yaml")
+
+	var ids []string
+	for _, node := range g.Nodes {
+		ids = append(ids, node.ID())
+	}
+	assert.Equal(t, []string{"service:api", "service:db", "service:worker", "network:backend", "volume:data"}, ids)
+	assert.Equal(t, []string{"8080:80"}, g.Nodes[0].Ports)
+	assert.Contains(t, g.Edges, Edge{Kind: EdgeDependsOn, From: "service:api", To: "service:db", Label: "service_healthy"})
+	assert.Contains(t, g.Edges, Edge{Kind: EdgeVolume, From: "service:db", To: "volume:data", Label: "/var/lib/postgresql/data"})
+	assert.Contains(t, g.Edges, Edge{Kind: EdgeNetwork, From: "service:worker", To: "network:backend"})
+}
+
+func TestGraph_Diff(t *testing.T) {
+	base := &Graph{
+		Nodes: []Node{{Kind: NodeService, Name: "web", Ports: []string{"80:80"}}, {Kind: NodeService, Name: "old"}},
+		Edges: []Edge{{Kind: EdgeDependsOn, From: "service:web", To: "service:old", Label: ConditionStarted}},
+	}
+	other := &Graph{
+		Nodes: []Node{{Kind: NodeService, Name: "web", Ports: []string{"8080:80"}}, {Kind: NodeService, Name: "new"}},
+		Edges: []Edge{{Kind: EdgeDependsOn, From: "service:web", To: "service:new", Label: ConditionStarted}},
+	}
+
+	diff := base.Diff(other)
+	require.Len(t, diff.Nodes, 3)
+	assert.Equal(t, Changed, diff.Nodes[0].Change)
+	assert.Equal(t, []string{"8080:80"}, diff.Nodes[0].Ports)
+	assert.Equal(t, Removed, diff.Nodes[1].Change)
+	assert.Equal(t, Added, diff.Nodes[2].Change)
+	assert.Equal(t, Removed, diff.Edges[0].Change)
+	assert.Equal(t, Added, diff.Edges[1].Change)
+}
+
+func TestGraph_Write(t *testing.T) {
+	g := loadGraph(t, "compose.yaml").Diff(loadGraph(t, "compose.yaml", "compose.dev.yaml"))
+
+	var text bytes.Buffer
+	require.NoError(t, g.Write(&text, GraphText))
+	assert.Contains(t, text.String(), "api [8080:80, 9229:9229] (changed)\n├── depends on db (service_healthy)\n")
+	assert.Contains(t, text.String(), "└── volume data -> /var/lib/postgresql/data\n")
+
+	var dot bytes.Buffer
+	require.NoError(t, g.Write(&dot, GraphDOT))
+	assert.Contains(t, dot.String(), "digraph dox {")
+	assert.Contains(t, dot.String(), `"service:api" [label="api\n8080:80, 9229:9229", shape=box, color=orange, fontcolor=orange];`)
+	assert.Contains(t, dot.String(), `"service:api" -> "service:db" [label="service_healthy"];`)
+
+	var mermaid bytes.Buffer
+	require.NoError(t, g.Write(&mermaid, GraphMermaid))
+	assert.Contains(t, mermaid.String(), "graph LR\n")
+	assert.Contains(t, mermaid.String(), `n4[("data")]`)
+	assert.Contains(t, mermaid.String(), `n0 -->|"service_healthy"| n1`)
+	assert.Contains(t, mermaid.String(), "class n0 changed")
+
+	assert.Error(t, g.Write(&text, "svg"))
+}
+
+func TestGraph_WriteMermaidDistinctIDs(t *testing.T) {
+	g := &Graph{
+		Nodes: []Node{{Kind: NodeService, Name: "api-1"}, {Kind: NodeService, Name: "api_1"}},
+		Edges: []Edge{
+			{Kind: EdgeDependsOn, From: "service:api-1", To: "service:api_1", Label: ConditionStarted},
+			{Kind: EdgeDependsOn, From: "service:api_1", To: "service:missing", Label: ConditionStarted},
+		},
+	}
+
+	var mermaid bytes.Buffer
+	require.NoError(t, g.Write(&mermaid, GraphMermaid))
+	assert.Equal(t, `graph LR
+  n0["api-1"]
+  n1["api_1"]
+  n2["missing"]
+  n0 -->|"service_started"| n1
+  n1 -->|"service_started"| n2
+`, mermaid.String())
+}
+
+func TestGraph_WriteRemovedNetworksAndVolumes(t *testing.T) {
+	base := &Graph{
+		Nodes: []Node{{Kind: NodeService, Name: "api"}, {Kind: NodeNetwork, Name: "back"}, {Kind: NodeVolume, Name: "data"}},
+		Edges: []Edge{
+			{Kind: EdgeNetwork, From: "service:api", To: "network:back"},
+			{Kind: EdgeVolume, From: "service:api", To: "volume:data", Label: "/data"},
+		},
+	}
+	g := base.Diff(&Graph{Nodes: []Node{{Kind: NodeService, Name: "api"}}})
+
+	var text bytes.Buffer
+	require.NoError(t, g.Write(&text, GraphText))
+	assert.Equal(t, `api
+├── network back (removed)
+└── volume data -> /data (removed)
+network back (removed)
+volume data (removed)
+`, text.String())
+
+	var dot bytes.Buffer
+	require.NoError(t, g.Write(&dot, GraphDOT))
+	assert.Contains(t, dot.String(), `"network:back" [label="back", shape=ellipse, color=red, fontcolor=red, style=dotted];`)
+	assert.Contains(t, dot.String(), `"service:api" -> "network:back" [arrowhead=none, color=red, fontcolor=red, style=dotted];`)
+}
@@ -0,0 +1,308 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/AkaraChen/dox/internal/compose"
+	"github.com/AkaraChen/dox/internal/config"
+	"gopkg.in/yaml.v3"
+)
+
+// rawProject holds the parsed but untyped content of one or more files
+type rawProject struct {
+	services map[string]map[string]any
+	networks map[string]any
+	volumes  map[string]any
+	sources  map[string][]string
+}
+
+func newRawProject() *rawProject {
+	return &rawProject{
+		services: map[string]map[string]any{},
+		networks: map[string]any{},
+		volumes:  map[string]any{},
+		sources:  map[string][]string{},
+	}
+}
+
+// loader loads compose files, caching parsed documents for extends
+type loader struct {
+	docs map[string]map[string]any
+}
+
+// LoadProfile loads the compose files selected for a dox profile in dir
+func LoadProfile(dir string, cfg *config.Config, profile string) (*Project, error) {
+	files, err := compose.NewBuilder(dir, cfg, profile).Files()
+	if err != nil {
+		return nil, err
+	}
+	return Load(files...)
+}
+
+// Load parses the compose files and merges them in order, the way
+// `docker compose -f a.yaml -f b.yaml` does
+func Load(files ...string) (*Project, error) {
+	l := &loader{docs: map[string]map[string]any{}}
+	raw, err := l.loadFiles(files, nil)
+	if err != nil {
+		return nil, err
+	}
+	return raw.project(files)
+}
+
+// loadFiles loads and merges files; stack holds the include chain for cycle detection
+func (l *loader) loadFiles(files []string, stack []string) (*rawProject, error) {
+	merged := newRawProject()
+	for _, file := range files {
+		raw, err := l.loadFile(file, stack)
+		if err != nil {
+			return nil, err
+		}
+		merged.merge(raw)
+	}
+	return merged, nil
+}
+
+// loadFile loads a single file with its includes and extends resolved
+func (l *loader) loadFile(path string, stack []string) (*rawProject, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	for i, seen := range stack {
+		if seen == abs {
+			cycle := append(append([]string{}, stack[i:]...), abs)
+			return nil, &config.ConfigError{Path: path, Err: fmt.Errorf("include cycle: %s", strings.Join(cycle, " -> "))}
+		}
+	}
+	stack = append(stack, abs)
+
+	doc, err := l.document(path)
+	if err != nil {
+		return nil, err
+	}
+
+	raw := newRawProject()
+	if err := l.loadIncludes(path, doc["include"], raw, stack); err != nil {
+		return nil, err
+	}
+
+	services, err := mappingOf(doc["services"], path, "services")
+	if err != nil {
+		return nil, err
+	}
+	for name := range services {
+		if _, ok := raw.services[name]; ok {
+			return nil, &config.ConfigError{Path: path, Err: fmt.Errorf("service '%s' conflicts with a service from an included file", name)}
+		}
+		svc, err := l.extend(path, name, nil)
+		if err != nil {
+			return nil, err
+		}
+		raw.services[name] = svc
+		raw.sources[name] = append(raw.sources[name], path)
+	}
+
+	for key, target := range map[string]map[string]any{"networks": raw.networks, "volumes": raw.volumes} {
+		resources, err := mappingOf(doc[key], path, key)
+		if err != nil {
+			return nil, err
+		}
+		for name, value := range resources {
+			target[name] = mergeValue(target[name], value)
+		}
+	}
+
+	return raw, nil
+}
+
+// document reads and parses a compose file, caching the result
+func (l *loader) document(path string) (map[string]any, error) {
+	if doc, ok := l.docs[path]; ok {
+		return doc, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, &config.MissingFileError{Path: path, Message: fmt.Sprintf("compose file '%s' not found", path)}
+		}
+		return nil, &config.ConfigError{Path: path, Err: err}
+	}
+
+	var doc map[string]any
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, &config.ConfigError{Path: path, Err: fmt.Errorf("failed to parse compose file: %w", err)}
+	}
+	if doc == nil {
+		doc = map[string]any{}
+	}
+	l.docs[path] = doc
+	return doc, nil
+}
+
+// loadIncludes loads the top-level include entries of a file into raw.
+// Included resources must not conflict with each other.
+func (l *loader) loadIncludes(path string, value any, raw *rawProject, stack []string) error {
+	if value == nil {
+		return nil
+	}
+	entries, ok := value.([]any)
+	if !ok {
+		return &config.ConfigError{Path: path, Err: fmt.Errorf("include must be a list")}
+	}
+
+	dir := filepath.Dir(path)
+	for _, entry := range entries {
+		var paths []string
+		switch e := entry.(type) {
+		case string:
+			paths = []string{e}
+		case map[string]any:
+			paths = stringList(e["path"])
+		}
+		if len(paths) == 0 {
+			return &config.ConfigError{Path: path, Err: fmt.Errorf("include entry without a path")}
+		}
+
+		for i, p := range paths {
+			if !filepath.IsAbs(p) {
+				paths[i] = filepath.Join(dir, p)
+			}
+		}
+		included, err := l.loadFiles(paths, stack)
+		if err != nil {
+			return err
+		}
+		for name, svc := range included.services {
+			if _, ok := raw.services[name]; ok {
+				return &config.ConfigError{Path: path, Err: fmt.Errorf("service '%s' is defined by more than one included file", name)}
+			}
+			raw.services[name] = svc
+			raw.sources[name] = included.sources[name]
+		}
+		for name, value := range included.networks {
+			raw.networks[name] = mergeValue(raw.networks[name], value)
+		}
+		for name, value := range included.volumes {
+			raw.volumes[name] = mergeValue(raw.volumes[name], value)
+		}
+	}
+	return nil
+}
+
+// extend returns the service from path with its extends chain merged in.
+// chain holds the services being extended for cycle detection.
+func (l *loader) extend(path, name string, chain []string) (map[string]any, error) {
+	ref := fmt.Sprintf("%s#%s", path, name)
+	for i, seen := range chain {
+		if seen == ref {
+			cycle := append(append([]string{}, chain[i:]...), ref)
+			return nil, &config.ConfigError{Path: path, Err: fmt.Errorf("extends cycle: %s", strings.Join(cycle, " -> "))}
+		}
+	}
+	chain = append(chain, ref)
+
+	doc, err := l.document(path)
+	if err != nil {
+		return nil, err
+	}
+	services, err := mappingOf(doc["services"], path, "services")
+	if err != nil {
+		return nil, err
+	}
+	value, ok := services[name]
+	if !ok {
+		return nil, &config.ConfigError{Path: path, Err: fmt.Errorf("service '%s' not found", name)}
+	}
+	svc, err := mappingOf(value, path, "services."+name)
+	if err != nil {
+		return nil, err
+	}
+	svc = copyMap(svc)
+
+	ext, ok := svc["extends"]
+	if !ok {
+		return svc, nil
+	}
+	delete(svc, "extends")
+
+	baseFile, baseName := path, ""
+	switch e := ext.(type) {
+	case string:
+		baseName = e
+	case map[string]any:
+		baseName = scalar(e["service"])
+		if file := scalar(e["file"]); file != "" {
+			baseFile = file
+			if !filepath.IsAbs(file) {
+				baseFile = filepath.Join(filepath.Dir(path), file)
+			}
+		}
+	}
+	if baseName == "" {
+		return nil, &config.ConfigError{Path: path, Err: fmt.Errorf("service '%s' extends without a service name", name)}
+	}
+
+	base, err := l.extend(baseFile, baseName, chain)
+	if err != nil {
+		return nil, err
+	}
+	return mergeService(base, svc), nil
+}
+
+// merge applies other on top of p with compose override semantics
+func (p *rawProject) merge(other *rawProject) {
+	for name, svc := range other.services {
+		if existing, ok := p.services[name]; ok {
+			p.services[name] = mergeService(existing, svc)
+		} else {
+			p.services[name] = svc
+		}
+		p.sources[name] = append(p.sources[name], other.sources[name]...)
+	}
+	for name, value := range other.networks {
+		p.networks[name] = mergeValue(p.networks[name], value)
+	}
+	for name, value := range other.volumes {
+		p.volumes[name] = mergeValue(p.volumes[name], value)
+	}
+}
+
+// project converts the merged raw content into the typed model
+func (p *rawProject) project(files []string) (*Project, error) {
+	project := &Project{
+		Files:    files,
+		Services: map[string]*Service{},
+		Networks: map[string]*Resource{},
+		Volumes:  map[string]*Resource{},
+	}
+	for name, raw := range p.services {
+		svc := decodeService(name, raw)
+		svc.Sources = p.sources[name]
+		project.Services[name] = svc
+	}
+	for name, raw := range p.networks {
+		project.Networks[name] = decodeResource(name, raw)
+	}
+	for name, raw := range p.volumes {
+		project.Volumes[name] = decodeResource(name, raw)
+	}
+	return project, nil
+}
+
+// mappingOf returns value as a mapping, treating null as empty
+func mappingOf(value any, path, key string) (map[string]any, error) {
+	if value == nil {
+		return map[string]any{}, nil
+	}
+	m, ok := value.(map[string]any)
+	if !ok {
+		return nil, &config.ConfigError{Path: path, Err: fmt.Errorf("%s must be a mapping", key)}
+	}
+	return m, nil
+}
@@ -0,0 +1,196 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// mergeService applies override on top of base following the compose
+// merge rules: mappings merge by key, volumes merge by target, ports and
+// other sequences append unique entries, command-like values are replaced.
+func mergeService(base, override map[string]any) map[string]any {
+	result := copyMap(base)
+	for key, value := range override {
+		existing, ok := result[key]
+		if !ok || existing == nil {
+			result[key] = value
+			continue
+		}
+
+		switch key {
+		case "environment", "labels", "annotations", "extra_hosts", "sysctls":
+			result[key] = mergeMaps(toMapping(existing), toMapping(value))
+		case "depends_on":
+			result[key] = mergeMaps(dependsOnMapping(existing), dependsOnMapping(value))
+		case "networks":
+			result[key] = mergeMaps(namedMapping(existing), namedMapping(value))
+		case "volumes":
+			result[key] = mergeMounts(existing, value)
+		case "ports", "expose", "env_file", "dns", "cap_add", "cap_drop", "devices", "security_opt", "profiles":
+			result[key] = appendUnique(existing, value)
+		case "build":
+			result[key] = mergeValue(buildMapping(existing), buildMapping(value))
+		case "command", "entrypoint":
+			result[key] = value
+		default:
+			result[key] = mergeValue(existing, value)
+		}
+	}
+	return result
+}
+
+// mergeValue deep merges mappings; any other override replaces the base
+func mergeValue(base, override any) any {
+	b, okBase := base.(map[string]any)
+	o, okOverride := override.(map[string]any)
+	if !okBase || !okOverride {
+		if override == nil {
+			return base
+		}
+		return override
+	}
+	return mergeMaps(b, o)
+}
+
+func mergeMaps(base, override map[string]any) map[string]any {
+	result := copyMap(base)
+	for key, value := range override {
+		result[key] = mergeValue(result[key], value)
+	}
+	return result
+}
+
+// mergeMounts merges volume lists, replacing base mounts with the same target
+func mergeMounts(base, override any) []any {
+	baseList, _ := base.([]any)
+	overrideList, _ := override.([]any)
+
+	targets := map[string]int{}
+	result := make([]any, 0, len(baseList)+len(overrideList))
+	for _, item := range append(append([]any{}, baseList...), overrideList...) {
+		target := parseMount(item).Target
+		if i, ok := targets[target]; ok && target != "" {
+			result[i] = item
+			continue
+		}
+		targets[target] = len(result)
+		result = append(result, item)
+	}
+	return result
+}
+
+// appendUnique appends the override sequence to the base, skipping duplicates
+func appendUnique(base, override any) []any {
+	seen := map[string]bool{}
+	var result []any
+	for _, list := range []any{base, override} {
+		items, ok := list.([]any)
+		if !ok && list != nil {
+			items = []any{list}
+		}
+		for _, item := range items {
+			key := fmt.Sprintf("%v", item)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// toMapping normalizes a KEY=VALUE list or a mapping into a mapping
+func toMapping(value any) map[string]any {
+	switch v := value.(type) {
+	case map[string]any:
+		return v
+	case []any:
+		m := make(map[string]any, len(v))
+		for _, item := range v {
+			key, val, found := strings.Cut(scalar(item), "=")
+			if found {
+				m[key] = val
+			} else {
+				m[key] = nil
+			}
+		}
+		return m
+	}
+	return map[string]any{}
+}
+
+// namedMapping normalizes a list of names or a mapping into a mapping
+func namedMapping(value any) map[string]any {
+	switch v := value.(type) {
+	case map[string]any:
+		return v
+	case []any:
+		m := make(map[string]any, len(v))
+		for _, item := range v {
+			m[scalar(item)] = nil
+		}
+		return m
+	}
+	return map[string]any{}
+}
+
+// dependsOnMapping normalizes depends_on into the long mapping syntax
+func dependsOnMapping(value any) map[string]any {
+	m := namedMapping(value)
+	for name, dep := range m {
+		if dep == nil {
+			m[name] = map[string]any{"condition": ConditionStarted}
+		}
+	}
+	return m
+}
+
+// buildMapping normalizes a build context string into the long syntax
+func buildMapping(value any) any {
+	if s, ok := value.(string); ok {
+		return map[string]any{"context": s}
+	}
+	return value
+}
+
+func copyMap(m map[string]any) map[string]any {
+	result := make(map[string]any, len(m))
+	for key, value := range m {
+		result[key] = value
+	}
+	return result
+}
+
+// scalar formats a YAML scalar as a string; null becomes empty
+func scalar(value any) string {
+	if value == nil {
+		return ""
+	}
+	return fmt.Sprintf("%v", value)
+}
+
+// stringList returns a string or a sequence of scalars as a list
+func stringList(value any) []string {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case []any:
+		list := make([]string, 0, len(v))
+		for _, item := range v {
+			list = append(list, scalar(item))
+		}
+		return list
+	}
+	return []string{scalar(value)}
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
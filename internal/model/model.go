@@ -0,0 +1,183 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Project is the merged compose model of a list of compose files
+type Project struct {
+	Files    []string
+	Services map[string]*Service
+	Networks map[string]*Resource
+	Volumes  map[string]*Resource
+}
+
+// Service is a single merged service definition
+type Service struct {
+	Name        string
+	Image       string
+	Build       *Build
+	Ports       []Port
+	Volumes     []Mount
+	Networks    []string
+	DependsOn   map[string]Dependency
+	Healthcheck *Healthcheck
+	Profiles    []string
+	Environment map[string]string
+	EnvFiles    []string
+	// Sources lists the files that define or override the service, in merge order
+	Sources []string
+	// Raw is the merged service definition as parsed from YAML
+	Raw map[string]any
+}
+
+// Build is the build section of a service
+type Build struct {
+	Context    string
+	Dockerfile string
+}
+
+// Port is a port mapping of a service
+type Port struct {
+	HostIP    string
+	Published string
+	Target    string
+	Protocol  string
+	// Spec is the short syntax as written when it could not be parsed, for
+	// example because it uses a variable; the other fields are then empty
+	Spec string
+}
+
+// Mount is a volume or bind mount of a service
+type Mount struct {
+	Type     string
+	Source   string
+	Target   string
+	ReadOnly bool
+}
+
+// Dependency is a depends_on entry of a service
+type Dependency struct {
+	Condition string
+}
+
+// Healthcheck is the healthcheck section of a service
+type Healthcheck struct {
+	Test        []string
+	Interval    string
+	Timeout     string
+	StartPeriod string
+	Retries     int
+	Disable     bool
+}
+
+// Resource is a top-level network or volume
+type Resource struct {
+	Name     string
+	Driver   string
+	External bool
+}
+
+// Mount types
+const (
+	MountVolume = "volume"
+	MountBind   = "bind"
+)
+
+// Default depends_on condition when none is given
+const ConditionStarted = "service_started"
+
+// ServiceNames returns all service names sorted
+func (p *Project) ServiceNames() []string {
+	names := make([]string, 0, len(p.Services))
+	for name := range p.Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Service returns the service with the given name
+func (p *Project) Service(name string) (*Service, bool) {
+	svc, ok := p.Services[name]
+	return svc, ok
+}
+
+// ProfileNames returns the compose profiles used by any service, sorted
+func (p *Project) ProfileNames() []string {
+	seen := map[string]bool{}
+	var names []string
+	for _, svc := range p.Services {
+		for _, profile := range svc.Profiles {
+			if !seen[profile] {
+				seen[profile] = true
+				names = append(names, profile)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ActiveServices returns the sorted names of services enabled when the given
+// compose profiles are active. Services without profiles are always enabled.
+func (p *Project) ActiveServices(profiles ...string) []string {
+	var names []string
+	for _, name := range p.ServiceNames() {
+		if p.Services[name].Enabled(profiles...) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// Enabled reports whether the service is enabled for the given compose profiles
+func (s *Service) Enabled(profiles ...string) bool {
+	if len(s.Profiles) == 0 {
+		return true
+	}
+	for _, want := range profiles {
+		for _, have := range s.Profiles {
+			if want == have || want == "*" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// String formats the port in compose short syntax
+func (p Port) String() string {
+	if p.Spec != "" {
+		return p.Spec
+	}
+	var parts []string
+	if strings.Contains(p.HostIP, ":") {
+		parts = append(parts, "["+p.HostIP+"]")
+	} else if p.HostIP != "" {
+		parts = append(parts, p.HostIP)
+	}
+	if p.Published != "" || p.HostIP != "" {
+		parts = append(parts, p.Published)
+	}
+	parts = append(parts, p.Target)
+	s := strings.Join(parts, ":")
+	if p.Protocol != "" && p.Protocol != "tcp" {
+		s += "/" + p.Protocol
+	}
+	return s
+}
+
+// String formats the mount in compose short syntax
+func (m Mount) String() string {
+	if m.Source == "" {
+		return m.Target
+	}
+	s := fmt.Sprintf("%s:%s", m.Source, m.Target)
+	if m.ReadOnly {
+		s += ":ro"
+	}
+	return s
+}
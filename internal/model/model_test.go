@@ -0,0 +1,247 @@
+package model
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/AkaraChen/dox/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupFixture(name string) string {
+	return filepath.Join("..", "..", "test", "fixtures", name)
+}
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+func TestLoad_OverrideSemantics(t *testing.T) {
+	dir := setupFixture("duplicate-services")
+	project, err := Load(filepath.Join(dir, "compose.yaml"), filepath.Join(dir, "compose.override.yaml"))
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"cache", "db", "web"}, project.ServiceNames())
+
+	web, ok := project.Service("web")
+	require.True(t, ok)
+	assert.Equal(t, "nginx:alpine", web.Image)
+	assert.Equal(t, []Port{{Published: "8080", Target: "80"}, {Published: "9090", Target: "80"}}, web.Ports)
+	assert.Equal(t, "production", web.Environment["ENV"])
+	assert.Len(t, web.Sources, 2)
+
+	db, _ := project.Service("db")
+	assert.Equal(t, "secret", db.Environment["POSTGRES_PASSWORD"])
+}
+
+func TestLoad_ExtendsAndInclude(t *testing.T) {
+	dir := setupFixture("compose-model")
+	project, err := Load(filepath.Join(dir, "compose.yaml"), filepath.Join(dir, "compose.dev.yaml"))
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"api", "db", "worker"}, project.ServiceNames())
+
+	api, _ := project.Service("api")
+	assert.Equal(t, "example/api:1.0", api.Image)
+	assert.Equal(t, "debug", api.Environment["LOG_LEVEL"])
+	assert.Equal(t, []Mount{{Type: MountBind, Source: "./dev-src", Target: "/app"}}, api.Volumes)
+	assert.Equal(t, []string{"8080:80", "9229:9229"}, []string{api.Ports[0].String(), api.Ports[1].String()})
+	assert.Equal(t, map[string]Dependency{"db": {Condition: "service_healthy"}}, api.DependsOn)
+	assert.Equal(t, []string{"backend"}, api.Networks)
+	require.NotNil(t, api.Healthcheck)
+	assert.Equal(t, []string{"CMD", "curl", "-f", "http://localhost"}, api.Healthcheck.Test)
+	assert.Equal(t, "10s", api.Healthcheck.Interval)
+	assert.Equal(t, 3, api.Healthcheck.Retries)
+	assert.NotContains(t, api.Raw, "extends")
+
+	worker, _ := project.Service("worker")
+	assert.Equal(t, "example/api:1.0", worker.Image)
+	assert.Equal(t, "info", worker.Environment["LOG_LEVEL"])
+	assert.Equal(t, []string{"jobs"}, worker.Profiles)
+
+	db, _ := project.Service("db")
+	assert.Equal(t, []string{"CMD-SHELL", "pg_isready"}, db.Healthcheck.Test)
+	assert.Equal(t, MountVolume, db.Volumes[0].Type)
+	assert.Equal(t, filepath.Join(dir, "infra", "compose.yaml"), db.Sources[0])
+
+	assert.Contains(t, project.Networks, "backend")
+	assert.Contains(t, project.Volumes, "data")
+}
+
+func TestLoadProfile(t *testing.T) {
+	dir := setupFixture("with-profiles")
+	cfg, _, err := config.LoadConfigFromDirectory(dir)
+	require.NoError(t, err)
+
+	project, err := LoadProfile(dir, cfg, "full")
+	require.NoError(t, err)
+
+	app, ok := project.Service("app")
+	require.True(t, ok)
+	assert.Equal(t, "myapp:latest", app.Image)
+	assert.Equal(t, ".", app.Build.Context)
+	assert.Equal(t, "production", app.Environment["NODE_ENV"])
+	assert.Len(t, app.Sources, 3)
+}
+
+func TestProject_ActiveServices(t *testing.T) {
+	project, err := Load(filepath.Join(setupFixture("compose-model"), "compose.yaml"))
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"jobs"}, project.ProfileNames())
+	assert.Equal(t, []string{"api", "db"}, project.ActiveServices())
+	assert.Equal(t, []string{"api", "db", "worker"}, project.ActiveServices("jobs"))
+}
+
+func TestLoad_ExtendsCycle(t *testing.T) {
+	dir := t.TempDir()
+	path := writeFile(t, dir, "compose.yaml", `
+services:
+  a:
+    extends: b
+  b:
+    extends: a
+`)
+
+	_, err := Load(path)
+	require.Error(t, err)
+	var configErr *config.ConfigError
+	assert.ErrorAs(t, err, &configErr)
+	assert.Contains(t, err.Error(), "extends cycle")
+}
+
+func TestLoad_IncludeCycle(t *testing.T) {
+	dir := t.TempDir()
+	path := writeFile(t, dir, "compose.yaml", "include: [other.yaml]\nservices: {}\n")
+	writeFile(t, dir, "other.yaml", "include: [compose.yaml]\n")
+
+	_, err := Load(path)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "include cycle")
+}
+
+func TestLoad_IncludeConflict(t *testing.T) {
+	dir := t.TempDir()
+	path := writeFile(t, dir, "compose.yaml", "include: [other.yaml]\nservices:\n  web:\n    image: a\n")
+	writeFile(t, dir, "other.yaml", "services:\n  web:\n    image: b\n")
+
+	_, err := Load(path)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "service 'web' conflicts")
+}
+
+func TestLoad_MissingFile(t *testing.T) {
+	_, err := Load(filepath.Join(t.TempDir(), "compose.yaml"))
+	var missing *config.MissingFileError
+	assert.ErrorAs(t, err, &missing)
+}
+
+func TestLoad_InvalidYAML(t *testing.T) {
+	path := writeFile(t, t.TempDir(), "compose.yaml", "services: [unclosed\n")
+	_, err := Load(path)
+	var configErr *config.ConfigError
+	assert.ErrorAs(t, err, &configErr)
+}
+
+func TestParsePort(t *testing.T) {
+	tests := []struct {
+		input any
+		want  Port
+	}{
+		{"80", Port{Target: "80"}},
+		{8080, Port{Target: "8080"}},
+		{"8080:80", Port{Published: "8080", Target: "80"}},
+		{"127.0.0.1:53:53/udp", Port{HostIP: "127.0.0.1", Published: "53", Target: "53", Protocol: "udp"}},
+		{map[string]any{"target": 80, "published": "8080"}, Port{Published: "8080", Target: "80"}},
+		{"[::1]:9090:90", Port{HostIP: "::1", Published: "9090", Target: "90"}},
+		{"[::]::53/udp", Port{HostIP: "::", Target: "53", Protocol: "udp"}},
+		{"${WEB_PORT:-8080}:80", Port{Spec: "${WEB_PORT:-8080}:80"}},
+		{"127.0.0.1:$PORT:80", Port{Spec: "127.0.0.1:$PORT:80"}},
+	}
+	for _, tt := range tests {
+		got, err := parsePort(tt.input)
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, got)
+	}
+
+	for _, spec := range []string{"1:2:3:4", "[::1]:80", "[::1:80:80"} {
+		_, err := parsePort(spec)
+		assert.Error(t, err, spec)
+	}
+
+	assert.Equal(t, "[::1]:9090:90", Port{HostIP: "::1", Published: "9090", Target: "90"}.String())
+}
+
+func TestLoad_KeepsUnparsedPorts(t *testing.T) {
+	path := writeFile(t, t.TempDir(), "compose.yaml", `services:
+  web:
+    image: nginx
+    ports: ["${WEB_PORT:-8080}:80", "1:2:3:4", "[::1]:9090:90"]
+`)
+	project, err := Load(path)
+	require.NoError(t, err, "one bad port does not fail the whole file")
+	assert.Equal(t, []Port{
+		{Spec: "${WEB_PORT:-8080}:80"},
+		{Spec: "1:2:3:4"},
+		{HostIP: "::1", Published: "9090", Target: "90"},
+	}, project.Services["web"].Ports)
+}
+
+func TestMergeService_Rules(t *testing.T) {
+	base := map[string]any{
+		"command":    []any{"a", "b"},
+		"depends_on": []any{"db"},
+		"labels":     []any{"x=1"},
+		"build":      ".",
+	}
+	override := map[string]any{
+		"command":    []any{"c"},
+		"depends_on": map[string]any{"cache": map[string]any{"condition": "service_healthy"}},
+		"labels":     map[string]any{"y": "2"},
+		"build":      map[string]any{"dockerfile": "Dockerfile.dev"},
+	}
+
+	merged := mergeService(base, override)
+	assert.Equal(t, []any{"c"}, merged["command"])
+	assert.Equal(t, map[string]any{"x": "1", "y": "2"}, merged["labels"])
+	assert.Equal(t, map[string]any{"context": ".", "dockerfile": "Dockerfile.dev"}, merged["build"])
+	assert.Equal(t, map[string]any{
+		"db":    map[string]any{"condition": ConditionStarted},
+		"cache": map[string]any{"condition": "service_healthy"},
+	}, merged["depends_on"])
+}
+
+func TestProject_ExpandService(t *testing.T) {
+	project, err := Load(filepath.Join(setupFixture("services"), "compose.yaml"))
+	require.NoError(t, err)
+
+	services, err := project.ExpandService("worker-*")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"worker-email", "worker-reports"}, services)
+
+	services, err = project.ExpandService("db")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"db"}, services)
+
+	_, err = project.ExpandService("dbb")
+	assert.EqualError(t, err, "no such service 'dbb' (did you mean 'db'?)")
+
+	_, err = project.ExpandService("zzzzzz")
+	assert.EqualError(t, err, "no such service 'zzzzzz'")
+
+	_, err = project.ExpandService("cache-?")
+	assert.EqualError(t, err, "no service matches 'cache-?'")
+}
+
+func TestSuggest(t *testing.T) {
+	candidates := []string{"api-gateway", "db", "worker-email"}
+	assert.Equal(t, "api-gateway", Suggest("apii", candidates))
+	assert.Equal(t, "api-gateway", Suggest("api-gatewy", candidates))
+	assert.Equal(t, "worker-email", Suggest("worker-emial", candidates))
+	assert.Equal(t, "", Suggest("frontend", candidates))
+}
@@ -0,0 +1,97 @@
+package model
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// UnknownServiceError reports a service argument that matches no service
+type UnknownServiceError struct {
+	Name       string
+	Suggestion string
+}
+
+func (e *UnknownServiceError) Error() string {
+	if IsPattern(e.Name) {
+		return fmt.Sprintf("no service matches '%s'", e.Name)
+	}
+	if e.Suggestion != "" {
+		return fmt.Sprintf("no such service '%s' (did you mean '%s'?)", e.Name, e.Suggestion)
+	}
+	return fmt.Sprintf("no such service '%s'", e.Name)
+}
+
+// IsPattern reports whether a service argument is a glob pattern
+func IsPattern(arg string) bool {
+	return strings.ContainsAny(arg, "*?[")
+}
+
+// ExpandService resolves a service name or glob pattern to the sorted
+// names of the matching services
+func (p *Project) ExpandService(arg string) ([]string, error) {
+	if !IsPattern(arg) {
+		if _, ok := p.Services[arg]; ok {
+			return []string{arg}, nil
+		}
+		return nil, &UnknownServiceError{Name: arg, Suggestion: Suggest(arg, p.ServiceNames())}
+	}
+
+	var matches []string
+	for _, name := range p.ServiceNames() {
+		ok, err := path.Match(arg, name)
+		if err != nil {
+			return nil, fmt.Errorf("invalid service pattern '%s': %w", arg, err)
+		}
+		if ok {
+			matches = append(matches, name)
+		}
+	}
+	if len(matches) == 0 {
+		return nil, &UnknownServiceError{Name: arg}
+	}
+	return matches, nil
+}
+
+// Suggest returns the candidate closest to name, or "" when none is close
+// enough to be a likely typo. Names of three or more characters are also
+// compared against candidate prefixes, so "apii" suggests "api-gateway".
+func Suggest(name string, candidates []string) string {
+	best, bestDistance := "", -1
+	for _, candidate := range candidates {
+		d := levenshtein(name, candidate)
+		if len(name) >= 3 && len(candidate) > len(name) {
+			d = min(d, levenshtein(name, candidate[:len(name)]))
+		}
+		if bestDistance < 0 || d < bestDistance {
+			best, bestDistance = candidate, d
+		}
+	}
+
+	// Allow roughly one edit per three characters, and always one
+	if bestDistance < 0 || bestDistance > max(1, len(name)/3) {
+		return ""
+	}
+	return best
+}
+
+// levenshtein returns the edit distance between a and b
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
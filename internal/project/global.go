@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 
 	"gopkg.in/yaml.v3"
@@ -12,14 +13,48 @@ import (
 
 // GlobalConfig represents the user's global dox configuration
 type GlobalConfig struct {
+	Runtime  string                  `yaml:"runtime,omitempty"`
 	Projects map[string]ProjectEntry `yaml:"projects,omitempty"`
 	Aliases  map[string]string       `yaml:"aliases,omitempty"`
 }
 
 // ProjectEntry represents a project alias in the global config
 type ProjectEntry struct {
-	Path        string `yaml:"path"`
-	Description string `yaml:"description,omitempty"`
+	Path         string            `yaml:"path"`
+	Description  string            `yaml:"description,omitempty"`
+	Profile      string            `yaml:"profile,omitempty"`
+	Env          map[string]string `yaml:"env,omitempty"`
+	Context      string            `yaml:"context,omitempty"`
+	IncludeInAll *bool             `yaml:"include_in_all,omitempty"`
+}
+
+// AllProjectsName is the reserved @project name that targets every project
+// included in @all
+const AllProjectsName = "all"
+
+// InAll reports whether the project takes part in @all invocations.
+// Projects are included unless include_in_all is explicitly false.
+func (e ProjectEntry) InAll() bool {
+	return e.IncludeInAll == nil || *e.IncludeInAll
+}
+
+// EnvList returns the entry's extra environment as sorted KEY=VALUE pairs.
+// A configured docker context is exported as DOCKER_CONTEXT.
+func (e ProjectEntry) EnvList() []string {
+	keys := make([]string, 0, len(e.Env))
+	for key := range e.Env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	env := make([]string, 0, len(keys)+1)
+	for _, key := range keys {
+		env = append(env, key+"="+e.Env[key])
+	}
+	if e.Context != "" {
+		env = append(env, "DOCKER_CONTEXT="+e.Context)
+	}
+	return env
 }
 
 // GetGlobalConfigPath returns the default path for the global config file
@@ -140,9 +175,10 @@ func IsAtProjectReference(input string) bool {
 
 // RemoteProject represents a resolved remote project reference
 type RemoteProject struct {
-	ProjectName     string
-	ProjectPath     string
+	ProjectName      string
+	ProjectPath      string
 	RemainingCommand string
+	Entry            ProjectEntry
 }
 
 // ResolveRemoteProject parses and resolves an @project reference
@@ -154,23 +190,48 @@ func (c *GlobalConfig) ResolveRemoteProject(input string) (*RemoteProject, error
 		return nil, nil
 	}
 
-	projectPath, exists := c.ResolveProjectPath(projectName)
+	entry, exists := c.Projects[projectName]
 	if !exists {
 		return nil, fmt.Errorf("project '%s' not found in global config", projectName)
 	}
 
 	return &RemoteProject{
 		ProjectName:      projectName,
-		ProjectPath:      projectPath,
+		ProjectPath:      entry.Path,
 		RemainingCommand: remainingCmd,
+		Entry:            entry,
 	}, nil
 }
 
+// ResolveAllProjects resolves every project included in @all, sorted by name
+func (c *GlobalConfig) ResolveAllProjects(remainingCmd string) []*RemoteProject {
+	names := make([]string, 0, len(c.Projects))
+	for name, entry := range c.Projects {
+		if entry.InAll() {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	projects := make([]*RemoteProject, 0, len(names))
+	for _, name := range names {
+		entry := c.Projects[name]
+		projects = append(projects, &RemoteProject{
+			ProjectName:      name,
+			ProjectPath:      entry.Path,
+			RemainingCommand: remainingCmd,
+			Entry:            entry,
+		})
+	}
+	return projects
+}
+
 // ProjectInfo represents a project with its metadata
 type ProjectInfo struct {
 	Name        string
 	Path        string
 	Description string
+	Profile     string
 }
 
 // ListProjects returns all projects with their metadata
@@ -181,6 +242,7 @@ func (c *GlobalConfig) ListProjects() []ProjectInfo {
 			Name:        name,
 			Path:        entry.Path,
 			Description: entry.Description,
+			Profile:     entry.Profile,
 		})
 	}
 	return projects
@@ -16,10 +16,11 @@ type History struct {
 
 // HistoryEntry represents a single command execution record
 type HistoryEntry struct {
-	Timestamp string `yaml:"timestamp"`
-	Command   string `yaml:"command"`
-	Directory string `yaml:"directory"`
-	ExitCode  int    `yaml:"exit_code"`
+	Timestamp   string `yaml:"timestamp"`
+	Command     string `yaml:"command"`
+	Directory   string `yaml:"directory"`
+	ExitCode    int    `yaml:"exit_code"`
+	Interrupted bool   `yaml:"interrupted,omitempty"`
 }
 
 // GetHistoryPath returns the default path for the history file
@@ -217,3 +217,53 @@ func TestListProjects(t *testing.T) {
 	assert.True(t, names["webapp"])
 	assert.True(t, names["api"])
 }
+
+func TestResolveRemoteProject_ProjectDefaults(t *testing.T) {
+	tempDir := t.TempDir()
+	configFile := filepath.Join(tempDir, "config.yaml")
+
+	content := []byte(`
+projects:
+  api:
+    path: /home/user/projects/api
+    profile: prod
+    context: shared-box
+    env:
+      TAG: stable
+      REGION: eu
+`)
+	err := os.WriteFile(configFile, content, 0644)
+	require.NoError(t, err)
+
+	cfg, err := LoadGlobalConfig(configFile)
+	require.NoError(t, err)
+
+	result, err := cfg.ResolveRemoteProject("@api c up")
+	require.NoError(t, err)
+	assert.Equal(t, "prod", result.Entry.Profile)
+	assert.True(t, result.Entry.InAll())
+	assert.Equal(t, []string{"REGION=eu", "TAG=stable", "DOCKER_CONTEXT=shared-box"}, result.Entry.EnvList())
+}
+
+func TestResolveAllProjects(t *testing.T) {
+	excluded := false
+	cfg := &GlobalConfig{
+		Projects: map[string]ProjectEntry{
+			"webapp":  {Path: "/home/user/webapp"},
+			"api":     {Path: "/home/user/api", Profile: "prod"},
+			"sandbox": {Path: "/home/user/sandbox", IncludeInAll: &excluded},
+		},
+	}
+
+	projects := cfg.ResolveAllProjects("c ps")
+	require.Len(t, projects, 2)
+	assert.Equal(t, "api", projects[0].ProjectName)
+	assert.Equal(t, "prod", projects[0].Entry.Profile)
+	assert.Equal(t, "webapp", projects[1].ProjectName)
+	assert.Equal(t, "c ps", projects[1].RemainingCommand)
+}
+
+func TestProjectEntry_EnvListEmpty(t *testing.T) {
+	entry := ProjectEntry{Path: "/home/user/api"}
+	assert.Empty(t, entry.EnvList())
+}
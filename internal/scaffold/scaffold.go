@@ -0,0 +1,267 @@
+package scaffold
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/AkaraChen/dox/internal/config"
+	"gopkg.in/yaml.v3"
+)
+
+// environments maps each kind of environment, in the order profiles are
+// proposed, to the slice and env file names that usually stand for it
+var environments = []struct {
+	kind  string
+	names []string
+}{
+	{"dev", []string{"dev", "development", "debug"}},
+	{"test", []string{"test", "testing", "ci", "e2e"}},
+	{"staging", []string{"staging", "stage", "stg", "preview"}},
+	{"prod", []string{"prod", "production", "live"}},
+}
+
+// templateSuffixes mark env files that are examples rather than real settings
+var templateSuffixes = []string{"example", "sample", "template", "dist"}
+
+// EnvFile is a detected .env.NAME file
+type EnvFile struct {
+	Name string
+	Path string
+}
+
+// Profile is a proposed profile for one environment
+type Profile struct {
+	Name   string
+	Slices []string
+	Env    string
+}
+
+// Proposal is a dox.yaml proposed from the files in a directory
+type Proposal struct {
+	BaseFile    string
+	Profiles    []Profile
+	EnvFiles    []EnvFile
+	OtherSlices []string          // slices that do not stand for an environment
+	SliceFiles  map[string]string // slice name -> compose file name
+}
+
+// Options select what Render writes
+type Options struct {
+	DefaultProfile string
+	Aliases        bool
+}
+
+// Propose inspects dir and proposes one profile per environment found among
+// the slice names and .env.NAME files
+func Propose(dir string) (*Proposal, error) {
+	discovery, err := config.DiscoverFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	p := &Proposal{SliceFiles: map[string]string{}}
+	if discovery.BaseFile != "" {
+		p.BaseFile = filepath.Base(discovery.BaseFile)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		name, ok := strings.CutPrefix(entry.Name(), ".env.")
+		if !ok || entry.IsDir() || isTemplate(name) {
+			continue
+		}
+		p.EnvFiles = append(p.EnvFiles, EnvFile{Name: name, Path: entry.Name()})
+	}
+
+	slices := make([]string, 0, len(discovery.Slices))
+	for name, file := range discovery.Slices {
+		slices = append(slices, name)
+		p.SliceFiles[name] = filepath.Base(file)
+	}
+	sort.Strings(slices)
+
+	assigned := map[string]bool{}
+	for _, env := range environments {
+		profile := Profile{}
+		for _, slice := range slices {
+			if environmentOf(slice) == env.kind {
+				profile.Slices = append(profile.Slices, slice)
+				assigned[slice] = true
+			}
+		}
+		for _, envFile := range p.EnvFiles {
+			if environmentOf(envFile.Name) == env.kind && profile.Env == "" {
+				profile.Env = envFile.Name
+			}
+		}
+
+		switch {
+		case len(profile.Slices) > 0:
+			profile.Name = profile.Slices[0]
+		case profile.Env != "":
+			profile.Name = profile.Env
+		default:
+			continue
+		}
+		p.Profiles = append(p.Profiles, profile)
+	}
+
+	for _, slice := range slices {
+		if !assigned[slice] {
+			p.OtherSlices = append(p.OtherSlices, slice)
+		}
+	}
+	return p, nil
+}
+
+// DefaultProfile proposes the development profile, or the first one
+func (p *Proposal) DefaultProfile() string {
+	for _, profile := range p.Profiles {
+		if environmentOf(profile.Name) == "dev" {
+			return profile.Name
+		}
+	}
+	if len(p.Profiles) > 0 {
+		return p.Profiles[0].Name
+	}
+	return ""
+}
+
+// Render writes the proposal as a commented dox.yaml of the current version
+func (p *Proposal) Render(opts Options) string {
+	var b strings.Builder
+	b.WriteString("# dox.yaml generated by dox init\n")
+	b.WriteString("# Run 'dox explain' to see which compose files a profile uses.\n")
+	fmt.Fprintf(&b, "version: %d\n", config.CurrentVersion)
+
+	if opts.DefaultProfile != "" {
+		b.WriteString("\n# Profile used when --profile is not given\n")
+		fmt.Fprintf(&b, "default_profile: %s\n", quote(opts.DefaultProfile))
+	}
+
+	if slices := p.slices(); len(slices) > 0 {
+		b.WriteString("\n# Compose files of each slice a profile can list\n")
+		b.WriteString("slices:\n")
+		for _, slice := range slices {
+			fmt.Fprintf(&b, "  %s: [%s]\n", quote(slice), quote(p.SliceFiles[slice]))
+		}
+		if len(p.OtherSlices) > 0 {
+			fmt.Fprintf(&b, "# Not used by a profile yet: %s\n", strings.Join(p.OtherSlices, ", "))
+		}
+	}
+
+	b.WriteString("\n")
+	if p.BaseFile != "" {
+		fmt.Fprintf(&b, "# Each profile adds the files of its slices to %s\n", p.BaseFile)
+	} else {
+		b.WriteString("# Each profile adds the files of its slices to the base compose file\n")
+	}
+	used := map[string]bool{}
+	if len(p.Profiles) == 0 {
+		b.WriteString("profiles: {}\n")
+		b.WriteString("#   dev:\n")
+		b.WriteString("#     slices: [dev]\n")
+		b.WriteString("#     env_file: .env.dev\n")
+	} else {
+		b.WriteString("profiles:\n")
+		for _, profile := range p.Profiles {
+			fmt.Fprintf(&b, "  %s:\n", quote(profile.Name))
+			quoted := make([]string, len(profile.Slices))
+			for i, slice := range profile.Slices {
+				quoted[i] = quote(slice)
+			}
+			fmt.Fprintf(&b, "    slices: [%s]\n", strings.Join(quoted, ", "))
+			if envFile := p.envFile(profile.Env); envFile != "" {
+				fmt.Fprintf(&b, "    env_file: %s\n", quote(envFile))
+				used[envFile] = true
+			}
+		}
+	}
+	var unused []string
+	for _, envFile := range p.EnvFiles {
+		if !used[envFile.Path] {
+			unused = append(unused, envFile.Path)
+		}
+	}
+	if len(unused) > 0 {
+		fmt.Fprintf(&b, "# Other env files found: %s\n", strings.Join(unused, ", "))
+		b.WriteString("# Set them as the env_file of a profile to use them.\n")
+	}
+
+	if opts.Aliases {
+		b.WriteString("\n# Shortcuts, run with 'dox c alias NAME'\n")
+		b.WriteString("aliases:\n")
+		b.WriteString("  fresh: \"down && up --build -d\"\n")
+		b.WriteString("  tail: \"logs -f --tail 100\"\n")
+	}
+
+	b.WriteString("\n# Commands run before or after compose commands, for example:\n")
+	b.WriteString("# hooks:\n")
+	b.WriteString("#   pre_up:\n")
+	b.WriteString("#     - \"echo 'Starting services...'\"\n")
+	b.WriteString("#   post_up:\n")
+	b.WriteString("#     - \"echo 'Services are ready!'\"\n")
+	return b.String()
+}
+
+// slices returns the names of all slices found, sorted
+func (p *Proposal) slices() []string {
+	var slices []string
+	for _, profile := range p.Profiles {
+		slices = append(slices, profile.Slices...)
+	}
+	slices = append(slices, p.OtherSlices...)
+	sort.Strings(slices)
+	return slices
+}
+
+// envFile returns the path of the env file with the given name
+func (p *Proposal) envFile(name string) string {
+	for _, envFile := range p.EnvFiles {
+		if name != "" && envFile.Name == name {
+			return envFile.Path
+		}
+	}
+	return ""
+}
+
+// environmentOf returns the kind of environment a name stands for, or ""
+func environmentOf(name string) string {
+	lower := strings.ToLower(name)
+	for _, env := range environments {
+		for _, candidate := range env.names {
+			if lower == candidate {
+				return env.kind
+			}
+		}
+	}
+	return ""
+}
+
+func isTemplate(name string) bool {
+	for _, suffix := range templateSuffixes {
+		if name == suffix || strings.HasSuffix(name, "."+suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+var plainScalar = regexp.MustCompile(`^[A-Za-z0-9_./-]+$`)
+
+// quote returns s as a YAML string, quoted unless it reads as one plain
+func quote(s string) string {
+	if plainScalar.MatchString(s) && !strings.HasPrefix(s, "-") {
+		var value any
+		if yaml.Unmarshal([]byte(s), &value) == nil && value == s {
+			return s
+		}
+	}
+	return fmt.Sprintf("%q", s)
+}
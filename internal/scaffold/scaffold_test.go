@@ -0,0 +1,104 @@
+package scaffold
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/AkaraChen/dox/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func touch(t *testing.T, dir string, names ...string) {
+	t.Helper()
+	for _, name := range names {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte("services: {}\n"), 0644))
+	}
+}
+
+func TestPropose(t *testing.T) {
+	dir := t.TempDir()
+	touch(t, dir, "compose.yaml", "compose.dev.yaml", "compose.debug.yaml", "compose.production.yaml",
+		"compose.ci.yaml", "compose.db.yaml", ".env.dev", ".env.prod", ".env.staging", ".env.example", ".env.prod.sample")
+
+	p, err := Propose(dir)
+	require.NoError(t, err)
+
+	assert.Equal(t, "compose.yaml", p.BaseFile)
+	assert.Equal(t, []EnvFile{
+		{Name: "dev", Path: ".env.dev"},
+		{Name: "prod", Path: ".env.prod"},
+		{Name: "staging", Path: ".env.staging"},
+	}, p.EnvFiles)
+	assert.Equal(t, []Profile{
+		{Name: "debug", Slices: []string{"debug", "dev"}, Env: "dev"},
+		{Name: "ci", Slices: []string{"ci"}},
+		{Name: "staging", Env: "staging"},
+		{Name: "production", Slices: []string{"production"}, Env: "prod"},
+	}, p.Profiles)
+	assert.Equal(t, []string{"db"}, p.OtherSlices)
+	assert.Equal(t, "debug", p.DefaultProfile())
+}
+
+func TestProposal_RenderIsValidConfig(t *testing.T) {
+	dir := t.TempDir()
+	touch(t, dir, "compose.yaml", "compose.dev.yaml", "compose.prod.yaml", "compose.db.yaml", ".env.dev", ".env.true")
+
+	p, err := Propose(dir)
+	require.NoError(t, err)
+	content := p.Render(Options{DefaultProfile: p.DefaultProfile(), Aliases: true})
+	assert.Contains(t, content, "  prod: [compose.prod.yaml]\n# Not used by a profile yet: db\n")
+	assert.Contains(t, content, "# Other env files found: .env.true\n")
+
+	path := filepath.Join(dir, "dox.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	cfg, err := config.LoadConfig(path)
+	require.NoError(t, err)
+
+	assert.Equal(t, config.CurrentVersion, cfg.Version)
+	assert.Equal(t, "dev", cfg.DefaultProfile)
+	assert.Empty(t, cfg.Deprecations())
+	assert.Equal(t, map[string]config.StringList{
+		"db":   {"compose.db.yaml"},
+		"dev":  {"compose.dev.yaml"},
+		"prod": {"compose.prod.yaml"},
+	}, cfg.Slices)
+	assert.Equal(t, config.Profile{Slices: []string{"dev"}, EnvFile: ".env.dev"}, cfg.Profiles["dev"])
+	assert.Equal(t, []string{"prod"}, cfg.Profiles["prod"].Slices)
+	assert.Contains(t, cfg.Aliases, "fresh")
+	assert.Empty(t, cfg.Hooks, "hooks are only suggested in comments")
+}
+
+func TestProposal_RenderYmlSlices(t *testing.T) {
+	dir := t.TempDir()
+	touch(t, dir, "docker-compose.yml", "compose.dev.yml", "compose.prod.yaml")
+
+	p, err := Propose(dir)
+	require.NoError(t, err)
+	content := p.Render(Options{DefaultProfile: p.DefaultProfile()})
+	assert.Contains(t, content, "  dev: [compose.dev.yml]\n")
+
+	path := filepath.Join(dir, "dox.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	cfg, err := config.LoadConfig(path)
+	require.NoError(t, err)
+	discovery, err := config.DiscoverFiles(dir)
+	require.NoError(t, err)
+	files, _, err := cfg.ResolveProfile("dev", discovery)
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "docker-compose.yml"), filepath.Join(dir, "compose.dev.yml")}, files)
+}
+
+func TestProposal_RenderEmpty(t *testing.T) {
+	p, err := Propose(t.TempDir())
+	require.NoError(t, err)
+	assert.Empty(t, p.DefaultProfile())
+
+	path := filepath.Join(t.TempDir(), "dox.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(p.Render(Options{})), 0644))
+	cfg, err := config.LoadConfig(path)
+	require.NoError(t, err)
+	assert.Empty(t, cfg.Profiles)
+	assert.Empty(t, cfg.Aliases)
+}
@@ -0,0 +1,349 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/AkaraChen/dox/internal/compose"
+	"github.com/AkaraChen/dox/internal/config"
+	"github.com/AkaraChen/dox/internal/lint"
+	"github.com/AkaraChen/dox/internal/project"
+)
+
+// Draft is the JSON Schema dialect of the generated schemas
+const Draft = "https://json-schema.org/draft/2020-12/schema"
+
+// Schema IDs, the published location of each schema
+const (
+	ConfigID = "https://raw.githubusercontent.com/AkaraChen/dox/main/schema/dox.schema.json"
+	GlobalID = "https://raw.githubusercontent.com/AkaraChen/dox/main/schema/global.schema.json"
+)
+
+// AliasPattern matches an alias definition: commands chained with && to run
+// in sequence or & to run in parallel, none of them empty
+const AliasPattern = `^[^&]*[^&\s][^&]*(&&?[^&]*[^&\s][^&]*)*$`
+
+// aliasNamePattern matches the name of an alias
+const aliasNamePattern = `^[^\s&]+$`
+
+// hookNamePattern matches pre_ and post_ hooks of compose commands that are
+// not listed in compose.Commands
+const hookNamePattern = `^(pre|post)_[a-z][a-z-]*$`
+
+// Schema is the subset of JSON Schema that dox generates
+type Schema struct {
+	Schema               string             `json:"$schema,omitempty"`
+	ID                   string             `json:"$id,omitempty"`
+	Ref                  string             `json:"$ref,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Deprecated           bool               `json:"deprecated,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Enum                 []any              `json:"enum,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	PropertyNames        *Schema            `json:"propertyNames,omitempty"`
+	AdditionalProperties any                `json:"additionalProperties,omitempty"` // false or a *Schema
+	OneOf                []*Schema          `json:"oneOf,omitempty"`
+	AnyOf                []*Schema          `json:"anyOf,omitempty"`
+	Defs                 map[string]*Schema `json:"$defs,omitempty"`
+}
+
+// Config returns the JSON Schema of dox.yaml
+func Config() (*Schema, error) {
+	return generate(reflect.TypeOf(config.Config{}), ConfigID, "dox.yaml")
+}
+
+// Global returns the JSON Schema of the global config,
+// ~/.config/dox/config.yaml
+func Global() (*Schema, error) {
+	return generate(reflect.TypeOf(project.GlobalConfig{}), GlobalID, "dox global config")
+}
+
+// Write writes s as indented JSON
+func Write(w io.Writer, s *Schema) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(s)
+}
+
+// HookNames returns the hook types listed in the schema: pre_ and post_ of
+// each compose command, on_failure and finally
+func HookNames() []string {
+	names := make([]string, 0, 2*len(compose.Commands)+2)
+	for _, command := range compose.Commands {
+		names = append(names, "pre_"+command, "post_"+command)
+	}
+	return append(names, "on_failure", "finally")
+}
+
+// generator builds a schema from Go types, placing named structs under $defs
+type generator struct {
+	defs  map[string]*Schema
+	types map[string]bool // structs the schema covers
+	used  map[string]bool // descriptions used
+	errs  []string
+}
+
+func generate(root reflect.Type, id, title string) (*Schema, error) {
+	g := &generator{defs: map[string]*Schema{}, types: map[string]bool{}, used: map[string]bool{}}
+	s := g.object(root)
+	s.Schema = Draft
+	s.ID = id
+	s.Title = title
+	if len(g.defs) > 0 {
+		s.Defs = g.defs
+	}
+
+	// Descriptions of fields that no longer exist are drift too, but only
+	// those of the types this schema covers
+	for key := range descriptions {
+		typeName, _, _ := strings.Cut(key, ".")
+		if g.types[typeName] && !g.used[key] {
+			g.errs = append(g.errs, fmt.Sprintf("description of %s matches no field", key))
+		}
+	}
+	if len(g.errs) > 0 {
+		sort.Strings(g.errs)
+		return nil, fmt.Errorf("schema out of sync with the config types: %s", strings.Join(g.errs, "; "))
+	}
+	return s, nil
+}
+
+// object returns the schema of a struct, with a property per yaml field
+func (g *generator) object(t reflect.Type) *Schema {
+	g.types[t.Name()] = true
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}, AdditionalProperties: false}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		key, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if key == "" || key == "-" {
+			continue
+		}
+
+		id := t.Name() + "." + key
+		g.used[id] = true
+		var prop *Schema
+		if custom, ok := fields[id]; ok {
+			prop = custom(g)
+		} else {
+			prop = g.typeSchema(id, field.Type)
+		}
+		description, ok := descriptions[id]
+		if !ok {
+			g.errs = append(g.errs, fmt.Sprintf("%s has no description", id))
+		}
+		prop.Description = description
+		prop.Deprecated = deprecated[id]
+		s.Properties[key] = prop
+	}
+	return s
+}
+
+// typeSchema returns the schema of a Go type
+func (g *generator) typeSchema(id string, t reflect.Type) *Schema {
+	if t == reflect.TypeOf(config.StringList{}) {
+		return g.ref("stringList", stringList)
+	}
+	switch t.Kind() {
+	case reflect.Pointer:
+		return g.typeSchema(id, t.Elem())
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Int:
+		return &Schema{Type: "integer"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Slice:
+		return &Schema{Type: "array", Items: g.typeSchema(id, t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.typeSchema(id, t.Elem())}
+	case reflect.Struct:
+		return g.ref(defName(t), func(g *generator) *Schema {
+			s := g.object(t)
+			description, ok := descriptions[t.Name()]
+			if !ok {
+				g.errs = append(g.errs, fmt.Sprintf("%s has no description", t.Name()))
+			}
+			g.used[t.Name()] = true
+			s.Description = description
+			return s
+		})
+	}
+	g.errs = append(g.errs, fmt.Sprintf("%s has unsupported type %s", id, t))
+	return &Schema{}
+}
+
+// ref returns a reference to the named definition, building it on first use
+func (g *generator) ref(name string, build func(*generator) *Schema) *Schema {
+	if _, ok := g.defs[name]; !ok {
+		g.defs[name] = &Schema{} // placeholder for recursive types
+		g.defs[name] = build(g)
+	}
+	return &Schema{Ref: "#/$defs/" + name}
+}
+
+// defName is the $defs name of a struct type: Profile becomes profile
+func defName(t reflect.Type) string {
+	name := []rune(t.Name())
+	name[0] = unicode.ToLower(name[0])
+	return string(name)
+}
+
+func stringList(*generator) *Schema {
+	return &Schema{
+		Description: "A string or a list of strings",
+		OneOf: []*Schema{
+			{Type: "string"},
+			{Type: "array", Items: &Schema{Type: "string"}},
+		},
+	}
+}
+
+func hooks(*generator) *Schema {
+	return &Schema{
+		Description: "Commands run around compose commands, by hook type. Each command is split into words like a shell would, honouring quotes and backslashes, and run without a shell.",
+		Type:        "object",
+		PropertyNames: &Schema{AnyOf: []*Schema{
+			{Enum: enum(HookNames())},
+			{Pattern: hookNamePattern},
+		}},
+		AdditionalProperties: &Schema{Type: "array", Items: &Schema{Type: "string", Pattern: `\S`}},
+	}
+}
+
+func aliases(*generator) *Schema {
+	return &Schema{
+		Description:   "Named shortcuts run with 'dox c alias NAME': compose commands or shell commands, chained with && to run in sequence or & to run in parallel. 'wait DURATION' pauses between commands.",
+		Type:          "object",
+		PropertyNames: &Schema{Pattern: aliasNamePattern},
+		AdditionalProperties: &Schema{
+			Type:    "string",
+			Pattern: AliasPattern,
+		},
+	}
+}
+
+func enum[T any](values []T) []any {
+	list := make([]any, len(values))
+	for i, value := range values {
+		list[i] = value
+	}
+	return list
+}
+
+// fields override the schema generated from the Go type of a field
+var fields = map[string]func(*generator) *Schema{
+	"Config.version": func(*generator) *Schema {
+		versions := make([]int, config.CurrentVersion)
+		for i := range versions {
+			versions[i] = i + 1
+		}
+		return &Schema{Type: "integer", Enum: enum(versions)}
+	},
+	"Config.runtime": func(*generator) *Schema {
+		return &Schema{Type: "string", Enum: enum(compose.RuntimeNames())}
+	},
+	"GlobalConfig.runtime": func(*generator) *Schema {
+		return &Schema{Type: "string", Enum: enum(compose.RuntimeNames())}
+	},
+	"Config.hooks":         func(g *generator) *Schema { return g.ref("hooks", hooks) },
+	"Profile.hooks":        func(g *generator) *Schema { return g.ref("hooks", hooks) },
+	"Config.aliases":       func(g *generator) *Schema { return g.ref("aliases", aliases) },
+	"Profile.aliases":      func(g *generator) *Schema { return g.ref("aliases", aliases) },
+	"GlobalConfig.aliases": func(g *generator) *Schema { return g.ref("aliases", aliases) },
+	"Profile.reset": func(*generator) *Schema {
+		field := &Schema{Type: "string", Enum: enum(config.ResetFields)}
+		return &Schema{OneOf: []*Schema{field, {Type: "array", Items: field}}}
+	},
+	"LintConfig.rules": func(*generator) *Schema {
+		ids := make([]string, len(lint.Rules))
+		for i, rule := range lint.Rules {
+			ids[i] = rule.ID
+		}
+		severities := []lint.Severity{lint.SeverityError, lint.SeverityWarning, lint.SeverityOff}
+		return &Schema{
+			Type:                 "object",
+			PropertyNames:        &Schema{Enum: enum(ids)},
+			AdditionalProperties: &Schema{Type: "string", Enum: enum(severities)},
+		}
+	},
+}
+
+// deprecated marks the version 1 keys that version 2 replaces, and the keys
+// that have no effect
+var deprecated = map[string]bool{
+	"Config.env_files":        true,
+	"Config.defaults":         true,
+	"Config.discovery":        true,
+	"DiscoveryConfig.enabled": true,
+	"DiscoveryConfig.pattern": true,
+	"DiscoveryConfig.base":    true,
+	"Profile.env":             true,
+	"Defaults.profile":        true,
+	"Defaults.slice":          true,
+	"Defaults.auto_detect":    true,
+}
+
+// descriptions document each type, and each field as Type.key. Every field
+// of the config types needs one; TestSchemaFiles fails on drift.
+var descriptions = map[string]string{
+	"Config.include":         "Config files merged under this one, in order. Paths are relative to this file, start with ~/, or are looked up in DOX_CONFIG_PATH.",
+	"Config.version":         "Config version. Version 2 adds default_profile and slices; run 'dox config migrate' to upgrade.",
+	"Config.default_profile": "Profile used when --profile is not given.",
+	"Config.slices":          "Compose files of each slice, as files, globs or directories relative to dox.yaml. A slice not listed here stands for compose.NAME.yaml.",
+	"Config.runtime":         "Compose runtime to use. Overrides the global config and is overridden by DOX_RUNTIME; detected from PATH when unset.",
+	"Config.discovery":       "Deprecated, has no effect: compose files are always discovered. Use slices to list them.",
+	"Config.profiles":        "Named sets of slices and the settings used with them.",
+	"Config.env_files":       "Env files by name, selected by the env of a profile. Deprecated: set env_file on each profile.",
+	"Config.defaults":        "Deprecated: use default_profile.",
+	"Config.aliases":         "Shortcuts run with 'dox c alias NAME'.",
+	"Config.hooks":           "Commands run before or after compose commands.",
+	"Config.lint":            "Settings for dox lint.",
+	"Config.vars":            "Variables for ${VAR} interpolation in the other values.",
+
+	"DiscoveryConfig":         "Deprecated, has no effect: compose files are always discovered.",
+	"DiscoveryConfig.enabled": "Deprecated, has no effect.",
+	"DiscoveryConfig.pattern": "Deprecated, has no effect. Use slices to list the slice files.",
+	"DiscoveryConfig.base":    "Deprecated, has no effect.",
+
+	"LintConfig":       "Settings for dox lint.",
+	"LintConfig.rules": "Severity of each lint rule: error, warning or off.",
+
+	"Profile":              "A set of compose slices and the settings used with them.",
+	"Profile.slices":       "Slices to add to the base compose file, in order. \"!NAME\" drops a slice an extended profile added.",
+	"Profile.env_file":     "Env file passed to compose.",
+	"Profile.env":          "Name of an entry of the top-level env_files. Deprecated: use env_file.",
+	"Profile.env_files":    "More env files, passed after env_file so later files win. A \"?\" prefix makes a file optional.",
+	"Profile.extends":      "Profiles whose settings this profile inherits.",
+	"Profile.environment":  "Variables exported to compose and hooks.",
+	"Profile.hooks":        "Hooks of this profile, run after the top-level and inherited hooks of the same type.",
+	"Profile.aliases":      "Aliases of this profile, merged over the inherited aliases.",
+	"Profile.project_name": "Compose project name.",
+	"Profile.context":      "Docker context, exported as DOCKER_CONTEXT.",
+	"Profile.reset":        "Fields not inherited from extended profiles.",
+
+	"Defaults":             "Deprecated: use default_profile.",
+	"Defaults.profile":     "Deprecated: use default_profile.",
+	"Defaults.slice":       "Deprecated, has no effect.",
+	"Defaults.auto_detect": "Deprecated, has no effect.",
+
+	"GlobalConfig.runtime":  "Compose runtime used by projects that do not set one. Overridden by DOX_RUNTIME; detected from PATH when unset.",
+	"GlobalConfig.projects": "Projects run from anywhere as @NAME. @all runs a command in every project.",
+	"GlobalConfig.aliases":  "Aliases available in every project. Aliases in dox.yaml take precedence.",
+
+	"ProjectEntry":                "A project run as @NAME.",
+	"ProjectEntry.path":           "Directory of the project.",
+	"ProjectEntry.description":    "Description of the project.",
+	"ProjectEntry.profile":        "Profile used unless --profile is given.",
+	"ProjectEntry.env":            "Variables exported for every command of the project.",
+	"ProjectEntry.context":        "Docker context, exported as DOCKER_CONTEXT.",
+	"ProjectEntry.include_in_all": "Whether @all includes the project. Defaults to true.",
+}
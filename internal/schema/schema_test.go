@@ -0,0 +1,98 @@
+package schema
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"testing"
+
+	"github.com/AkaraChen/dox/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSchemaFiles fails when the published schemas drift from the Go types
+func TestSchemaFiles(t *testing.T) {
+	for name, generate := range map[string]func() (*Schema, error){
+		"dox.schema.json":    Config,
+		"global.schema.json": Global,
+	} {
+		s, err := generate()
+		require.NoError(t, err)
+		var buf bytes.Buffer
+		require.NoError(t, Write(&buf, s))
+
+		path := filepath.Join("..", "..", "schema", name)
+		published, err := os.ReadFile(path)
+		require.NoError(t, err)
+		flag := ""
+		if name == "global.schema.json" {
+			flag = " --global"
+		}
+		assert.Equal(t, string(published), buf.String(),
+			"%s is out of date; run 'go run . config schema%s > schema/%s'", path, flag, name)
+	}
+}
+
+func TestConfig(t *testing.T) {
+	s, err := Config()
+	require.NoError(t, err)
+
+	assert.Equal(t, false, s.AdditionalProperties, "unknown keys are typos")
+	assert.Equal(t, []any{1, 2}, s.Properties["version"].Enum)
+	assert.Equal(t, "#/$defs/profile", s.Properties["profiles"].AdditionalProperties.(*Schema).Ref)
+	assert.True(t, s.Properties["env_files"].Deprecated)
+	assert.True(t, s.Defs["profile"].Properties["env"].Deprecated)
+	assert.Equal(t, "#/$defs/hooks", s.Defs["profile"].Properties["hooks"].Ref)
+	assert.Contains(t, s.Defs["hooks"].PropertyNames.AnyOf[0].Enum, "pre_up")
+	assert.Contains(t, s.Defs["lintConfig"].Properties["rules"].PropertyNames.Enum, "port-collision")
+}
+
+func TestGenerate_Drift(t *testing.T) {
+	type Undocumented struct {
+		Name string `yaml:"name"`
+	}
+	_, err := generate(reflect.TypeOf(Undocumented{}), "", "")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Undocumented.name has no description")
+
+	descriptions["Profile.removed"] = "A field that no longer exists."
+	defer delete(descriptions, "Profile.removed")
+	_, err = Config()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "description of Profile.removed matches no field")
+}
+
+func TestAliasPattern(t *testing.T) {
+	pattern := regexp.MustCompile(AliasPattern)
+	for _, alias := range []string{"up -d", "down && up --build -d", "up -d db & up -d cache && logs -f", "wait 5s"} {
+		assert.True(t, pattern.MatchString(alias), alias)
+	}
+	for _, alias := range []string{"", "  ", "down &&", "&& up", "up & & down", "up &&& down"} {
+		assert.False(t, pattern.MatchString(alias), alias)
+	}
+}
+
+// TestFixtures checks the aliases and hooks of the fixtures against the schema
+func TestFixtures(t *testing.T) {
+	aliasPattern := regexp.MustCompile(AliasPattern)
+	hookPattern := regexp.MustCompile(hookNamePattern)
+
+	paths, err := filepath.Glob(filepath.Join("..", "..", "test", "fixtures", "*", "dox.yaml"))
+	require.NoError(t, err)
+	require.NotEmpty(t, paths)
+	for _, path := range paths {
+		cfg, err := config.LoadConfig(path)
+		require.NoError(t, err, path)
+
+		for name, alias := range cfg.Aliases {
+			assert.True(t, aliasPattern.MatchString(alias), "%s: alias %s: %q", path, name, alias)
+		}
+		for name := range cfg.Hooks {
+			assert.True(t, slices.Contains(HookNames(), name) || hookPattern.MatchString(name), "%s: hook %s", path, name)
+		}
+	}
+}